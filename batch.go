@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// manifest lists the templates of a batch run, see -manifest.
+type manifest struct {
+	Templates []manifestEntry `json:"templates"`
+}
+
+// manifestEntry is a template, or with Sheets a template per worksheet, to
+// generate. Settings left empty come from the flags, except for the input,
+// Sheets, Output and Update.
+type manifestEntry struct {
+	// Source is a source URI or file path; SheetID is short for a gsheet://
+	// source.
+	Source  string `json:"source"`
+	SheetID string `json:"sheet_id"`
+	Range   string `json:"range"`
+	Sheets  string `json:"sheets"`
+	Name    string `json:"name"`
+	Theme   string `json:"theme"`
+	Output  string `json:"output"`
+	Update  string `json:"update"`
+}
+
+// label names the entry in logs and the summary.
+func (e manifestEntry) label() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	if e.SheetID != "" {
+		return e.SheetID
+	}
+	return e.Source
+}
+
+// readManifest reads the manifest at path, with relative file paths made
+// relative to its directory. Entries writing the same output, or updating
+// from the output of another entry, which is written concurrently, are an
+// error; so are those whose paths collide with the per-worksheet paths of an
+// entry with sheets.
+func readManifest(path string) (manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return manifest{}, err
+	}
+	defer f.Close()
+
+	var m manifest
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return manifest{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(m.Templates) == 0 {
+		return manifest{}, fmt.Errorf("%s: no templates listed", path)
+	}
+
+	dir := filepath.Dir(path)
+	outputs := make(map[string]int)
+	for i := range m.Templates {
+		e := &m.Templates[i]
+		switch {
+		case (e.Source == "") == (e.SheetID == ""):
+			err = errors.New("want either a source or a sheet_id")
+		case e.Output == "":
+			err = errors.New("no output given")
+		case e.Output == "-":
+			err = errors.New("output cannot be stdout")
+		}
+		if err != nil {
+			return manifest{}, fmt.Errorf("%s: template %d: %w", path, i+1, err)
+		}
+		if e.SheetID != "" {
+			e.Source = "gsheet://" + e.SheetID
+		}
+		e.Source = resolveSource(dir, e.Source)
+		e.Output = resolvePath(dir, e.Output)
+		e.Update = resolvePath(dir, e.Update)
+		if j, ok := outputs[e.Output]; ok {
+			return manifest{}, fmt.Errorf("%s: templates %d and %d are both written to %s", path, j+1, i+1, e.Output)
+		}
+		outputs[e.Output] = i
+	}
+	updates := make(map[string]int)
+	for i, e := range m.Templates {
+		if e.Update == "" {
+			continue
+		}
+		if j, ok := outputs[e.Update]; ok && j != i {
+			return manifest{}, fmt.Errorf("%s: template %d updates %s, the output of template %d", path, i+1, e.Update, j+1)
+		}
+		if j, ok := updates[e.Update]; ok {
+			return manifest{}, fmt.Errorf("%s: templates %d and %d both update %s", path, j+1, i+1, e.Update)
+		}
+		updates[e.Update] = i
+	}
+	// an entry with sheets writes and updates a path per worksheet
+	for i, e := range m.Templates {
+		if e.Sheets == "" {
+			continue
+		}
+		for j, other := range m.Templates {
+			if other.Sheets != "" {
+				continue
+			}
+			err = nil
+			switch {
+			case e.uses(e.Output, other.Output):
+				err = fmt.Errorf("templates %d and %d are both written to %s", min(i, j)+1, max(i, j)+1, other.Output)
+			case e.uses(e.Output, other.Update):
+				err = fmt.Errorf("template %d updates %s, an output of template %d", j+1, other.Update, i+1)
+			case e.uses(e.Update, other.Output):
+				err = fmt.Errorf("template %d updates %s, the output of template %d", i+1, other.Output, j+1)
+			case e.uses(e.Update, other.Update):
+				err = fmt.Errorf("templates %d and %d both update %s", min(i, j)+1, max(i, j)+1, other.Update)
+			}
+			if err != nil {
+				return manifest{}, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// uses reports whether file is one of the paths the entry uses for pattern,
+// its output or update path, which with Sheets stands for a path per
+// worksheet.
+func (e manifestEntry) uses(pattern, file string) bool {
+	switch {
+	case pattern == "" || file == "":
+		return false
+	case e.Sheets == "":
+		return pattern == file
+	}
+	return sheetPathPattern(pattern).MatchString(file)
+}
+
+// resolvePath returns path relative to dir unless it is absolute or empty,
+// cleaned so that equal paths compare equal.
+func resolvePath(dir, path string) string {
+	switch {
+	case path == "":
+		return ""
+	case filepath.IsAbs(path):
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolveSource resolves the path of a file source URI like resolvePath.
+func resolveSource(dir, uri string) string {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		scheme, rest = "file", uri
+	}
+	if scheme != "file" {
+		return uri
+	}
+	path, query, hasQuery := strings.Cut(rest, "?")
+	uri = "file://" + resolvePath(dir, path)
+	if hasQuery {
+		uri += "?" + query
+	}
+	return uri
+}
+
+// batchResult is the outcome of a manifest entry.
+type batchResult struct {
+	entry    manifestEntry
+	err      error
+	duration time.Duration
+}
+
+// runBatch generates the templates of the manifest at path, g.cfg.Jobs at a
+// time, and writes a summary of the results to w. A failing entry does not
+// stop the others.
+func runBatch(ctx context.Context, g *generator, path string, w io.Writer) error {
+	m, err := readManifest(path)
+	if err != nil {
+		return err
+	}
+
+	results := make([]batchResult, len(m.Templates))
+	slots := make(chan struct{}, g.cfg.Jobs)
+	var wg sync.WaitGroup
+	for i, e := range m.Templates {
+		wg.Add(1)
+		go func(i int, e manifestEntry) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			start := time.Now()
+			logger := log.New(log.Writer(), log.Prefix()+e.label()+": ", log.Flags())
+			err := g.forEntry(e).run(ctx, logger)
+			if err != nil {
+				logger.Print(err)
+			}
+			results[i] = batchResult{entry: e, err: err, duration: time.Since(start)}
+		}(i, e)
+	}
+	wg.Wait()
+
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		status, detail := "ok", r.entry.Output
+		if r.err != nil {
+			// the full error is in the log
+			detail, _, _ = strings.Cut(r.err.Error(), "\n")
+			status = "FAILED"
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", status, r.entry.label(), r.duration.Round(time.Millisecond), detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d of %d templates generated\n", len(results)-failed, len(results))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d templates failed", failed, len(results))
+	}
+	return nil
+}
+
+// forEntry returns a generator for the manifest entry e, with the settings
+// of g where e leaves them out.
+func (g *generator) forEntry(e manifestEntry) *generator {
+	entry := *g
+	entry.cfg.Source, entry.cfg.SheetID, entry.cfg.CSVPath, entry.cfg.XLSXPath = e.Source, "", "", ""
+	entry.cfg.Sheets, entry.cfg.OutputPath, entry.cfg.PreviousPath = e.Sheets, e.Output, e.Update
+	if e.Range != "" {
+		entry.cfg.ReadRange = e.Range
+	}
+	if e.Name != "" {
+		entry.cfg.TemplateName = e.Name
+	}
+	if e.Theme != "" {
+		entry.parser.Theme = e.Theme
+	}
+	return &entry
+}
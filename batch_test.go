@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		manifest string
+		want     []manifestEntry
+		wantErr  string
+	}{
+		{
+			name: "paths",
+			manifest: `{"templates": [
+				{"sheet_id": "abc", "output": "out/a.json", "update": "out/a.json"},
+				{"source": "b.csv?skip=2", "sheets": "*", "output": "/tmp/b_{sheet}.json"}
+			]}`,
+			want: []manifestEntry{
+				{Source: "gsheet://abc", SheetID: "abc", Output: filepath.Join(dir, "out/a.json"), Update: filepath.Join(dir, "out/a.json")},
+				{Source: "file://" + filepath.Join(dir, "b.csv") + "?skip=2", Sheets: "*", Output: "/tmp/b_{sheet}.json"},
+			},
+		},
+		{
+			name:     "unknown field",
+			manifest: `{"templates": [{"source": "a.csv", "output": "a.json", "ouput": "b.json"}]}`,
+			wantErr:  `unknown field "ouput"`,
+		},
+		{
+			name:     "no templates",
+			manifest: `{"templates": []}`,
+			wantErr:  "no templates listed",
+		},
+		{
+			name:     "source and sheet ID",
+			manifest: `{"templates": [{"source": "a.csv", "sheet_id": "abc", "output": "a.json"}]}`,
+			wantErr:  "template 1: want either a source or a sheet_id",
+		},
+		{
+			name:     "no output",
+			manifest: `{"templates": [{"source": "a.csv"}]}`,
+			wantErr:  "template 1: no output given",
+		},
+		{
+			name:     "stdout",
+			manifest: `{"templates": [{"source": "a.csv", "output": "-"}]}`,
+			wantErr:  "template 1: output cannot be stdout",
+		},
+		{
+			name: "same output",
+			manifest: `{"templates": [
+				{"source": "a.csv", "output": "out.json"},
+				{"source": "b.csv", "output": "./x/../out.json"}
+			]}`,
+			wantErr: "templates 1 and 2 are both written to " + filepath.Join(dir, "out.json"),
+		},
+		{
+			name: "update from another output",
+			manifest: `{"templates": [
+				{"source": "a.csv", "output": "a.json"},
+				{"source": "b.csv", "output": "b.json", "update": "a.json"}
+			]}`,
+			wantErr: "template 2 updates " + filepath.Join(dir, "a.json") + ", the output of template 1",
+		},
+		{
+			name: "same update",
+			manifest: `{"templates": [
+				{"source": "a.csv", "output": "a.json", "update": "old.json"},
+				{"source": "b.csv", "output": "b.json", "update": "old.json"}
+			]}`,
+			wantErr: "templates 1 and 2 both update " + filepath.Join(dir, "old.json"),
+		},
+		{
+			name: "output of a worksheet",
+			manifest: `{"templates": [
+				{"source": "a.xlsx", "sheets": "*", "output": "out.json"},
+				{"source": "b.csv", "output": "out_retail_eu.json"}
+			]}`,
+			wantErr: "templates 1 and 2 are both written to " + filepath.Join(dir, "out_retail_eu.json"),
+		},
+		{
+			name: "update from the output of a worksheet",
+			manifest: `{"templates": [
+				{"source": "a.csv", "output": "a.json", "update": "out/travel.json"},
+				{"source": "b.xlsx", "sheets": "*", "output": "out/{sheet}.json"}
+			]}`,
+			wantErr: "template 1 updates " + filepath.Join(dir, "out/travel.json") + ", an output of template 2",
+		},
+		{
+			name: "worksheet updates another output",
+			manifest: `{"templates": [
+				{"source": "a.xlsx", "sheets": "*", "output": "new/{sheet}.json", "update": "old/{sheet}.json"},
+				{"source": "b.csv", "output": "old/b.json"}
+			]}`,
+			wantErr: "template 1 updates " + filepath.Join(dir, "old/b.json") + ", the output of template 2",
+		},
+		{
+			name: "update of a worksheet",
+			manifest: `{"templates": [
+				{"source": "a.csv", "output": "a.json", "update": "old_retail.json"},
+				{"source": "b.xlsx", "sheets": "*", "output": "b.json", "update": "old.json"}
+			]}`,
+			wantErr: "templates 1 and 2 both update " + filepath.Join(dir, "old_retail.json"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "manifest.json")
+			if err := os.WriteFile(path, []byte(tt.manifest), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := readManifest(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(m.Templates, tt.want) {
+				t.Errorf("templates:\n got %+v\nwant %+v", m.Templates, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.csv": "title\n\nheader\nOverview,KPIs,KPI,Spend,,,Spend,spend\n",
+		"bad.csv":  "title\n\nheader\nOverview,KPIs,KPI,Spend,,,Spend,\n",
+		"manifest.json": `{"templates": [
+			{"source": "good.csv", "name": "good", "output": "good.json"},
+			{"source": "bad.csv", "name": "bad", "output": "bad.json"}
+		]}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var logs bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&logs)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	manifest := filepath.Join(dir, "manifest.json")
+	// the worksheets are given per entry
+	for _, flag := range [][]string{{"-sheets", "*"}, {"-output", "out.json"}, {"-update", "old.json"}} {
+		if _, err := parseFlags(append([]string{"-manifest", manifest}, flag...), io.Discard); err == nil {
+			t.Errorf("parseFlags with -manifest and %s: no error", flag[0])
+		}
+	}
+	cfg, err := parseFlags([]string{"-manifest", manifest}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary bytes.Buffer
+	err = runBatch(context.Background(), g, cfg.ManifestPath, &summary)
+	if err == nil || err.Error() != "1 of 2 templates failed" {
+		t.Errorf("runBatch error = %v", err)
+	}
+
+	// the durations vary
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(summary.String()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 3 && (fields[0] == "ok" || fields[0] == "FAILED") {
+			fields = append(fields[:2], fields[3:]...)
+		}
+		got = append(got, strings.Join(fields, " "))
+	}
+	want := []string{
+		"ok good " + filepath.Join(dir, "good.json"),
+		"FAILED bad unable to parse template rows: bad.csv!G4: metric name present but metric ID (H) missing",
+		"1 of 2 templates generated",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary:\n got %q\nwant %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "good.json")); err != nil {
+		t.Errorf("good template not written: %v", err)
+	}
+	if line := "good: template JSON written to " + filepath.Join(dir, "good.json"); !strings.Contains(logs.String(), line) {
+		t.Errorf("log has no line %q:\n%s", line, logs.String())
+	}
+}
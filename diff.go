@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/surya-pixis/template-generator/templategen"
+)
+
+// diffConfig holds the command-line settings of the diff command.
+type diffConfig struct {
+	OldPath   string
+	NewPath   string
+	PatchPath string
+	Schema    string
+}
+
+const diffUsageHeader = `Usage: template-generator diff [flags] <old.json> <new.json>
+
+Compares two template JSONs of any schema version structurally and reports
+the boards, tabs, grids, charts and metrics added, removed, moved, renamed
+or changed. Objects are matched by ID, then by title path, so templates
+generated with random IDs compare too. Either file can be - for stdin.
+
+  + added       - removed       > moved       ~ renamed or changed
+
+With -patch the JSON Patch (RFC 6902) turning the old JSON into the new one
+is written too; with -patch - it is written to stdout instead of the report.
+The exit status is 1 when the templates differ, as with diff(1).
+
+Flags:
+`
+
+func diffMain(args []string) {
+	cfg, err := parseDiffFlags(args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	differ, err := runDiff(cfg, os.Stdout)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	if differ {
+		os.Exit(1)
+	}
+}
+
+// parseDiffFlags reads the diff flags and files from args, like parseFlags.
+func parseDiffFlags(args []string, output io.Writer) (diffConfig, error) {
+	var cfg diffConfig
+
+	fs := flag.NewFlagSet("template-generator diff", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), diffUsageHeader)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.PatchPath, "patch", "", "file to write the JSON Patch to, or - for stdout")
+	fs.StringVar(&cfg.Schema, "schema", envOr("TEMPLATEGEN_SCHEMA", strconv.Itoa(templategen.SchemaVersion)), "schema version of the JSON the patch applies to, or legacy (1) [TEMPLATEGEN_SCHEMA]")
+
+	if err := fs.Parse(args); err != nil {
+		return diffConfig{}, err
+	}
+	if fs.NArg() != 2 {
+		err := fmt.Errorf("want two template files, got %d", fs.NArg())
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return diffConfig{}, err
+	}
+	if _, err := templategen.ParseSchema(cfg.Schema); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return diffConfig{}, err
+	}
+	if fs.Arg(0) == "-" && fs.Arg(1) == "-" {
+		err := errors.New("only one template can be read from stdin")
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return diffConfig{}, err
+	}
+	cfg.OldPath, cfg.NewPath = fs.Arg(0), fs.Arg(1)
+	return cfg, nil
+}
+
+// runDiff writes the report, or the patch, to w, and reports whether the
+// templates differ.
+func runDiff(cfg diffConfig, w io.Writer) (bool, error) {
+	schema, _ := templategen.ParseSchema(cfg.Schema)
+	old, err := readTemplate(cfg.OldPath)
+	if err != nil {
+		return false, err
+	}
+	new, err := readTemplate(cfg.NewPath)
+	if err != nil {
+		return false, err
+	}
+
+	changes := templategen.Diff(old, new)
+	if cfg.PatchPath != "-" {
+		for _, c := range changes {
+			fmt.Fprintln(w, c)
+		}
+	}
+	if cfg.PatchPath == "" {
+		return len(changes) > 0, nil
+	}
+
+	patch, err := templategen.TemplatePatch(old, new, schema)
+	if err != nil {
+		return false, err
+	}
+	if patch == nil {
+		patch = []templategen.PatchOp{}
+	}
+	data, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	data = append(data, '\n')
+	if cfg.PatchPath == "-" {
+		_, err = w.Write(data)
+	} else {
+		err = os.WriteFile(cfg.PatchPath, data, 0o644)
+	}
+	// IDs and styling Diff leaves out can still differ
+	return len(changes) > 0 || len(patch) > 0, err
+}
@@ -3,701 +3,487 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/google/uuid"
-	"google.golang.org/api/option"
-	"google.golang.org/api/sheets/v4"
+	"github.com/surya-pixis/template-generator/templategen"
 )
 
-type GlobalTemplateConfig struct {
-	Global Global `json:"global"`
+// config holds the command-line settings of a generator run.
+type config struct {
+	Source          string
+	ManifestPath    string
+	Jobs            int
+	SheetID         string
+	CredentialsFile string
+	ReadRange       string
+	Sheets          string
+	CSVPath         string
+	CSVSkip         int
+	XLSXPath        string
+	TemplateName    string
+	IDMode          string
+	PreviousPath    string
+	StrictBoardType bool
+	Header          bool
+	Aliases         aliasFlag
+	CatalogPath     string
+	StrictCatalog   bool
+	Layout          bool
+	GridColumns     int
+	ThemePaths      string
+	Theme           string
+	Schema          string
+	PrintSchema     bool
+	Strict          bool
+	AllowErrors     bool
+	OutputPath      string
 }
 
-type Global struct {
-	TemplateID      string            `json:"template_id"`
-	TemplateName    string            `json:"template_name"`
-	TemplateConfigs []TemplateConfigs `json:"template_configs"`
-}
+const usageHeader = `Usage: template-generator [flags]
+       template-generator render [flags]
+       template-generator diff [flags] <old.json> <new.json>
 
-type TemplateConfigs struct {
-	TemplateConfigName string `json:"template_config_name"`
-	TemplateType       string `json:"template_type"`
-	BoardType          string `json:"board_type"`
-	TemplateConfigID   string `json:"template_config_id"`
-	Tabs               []Tab  `json:"tabs"`
-}
+Reads a template layout from a Google Sheet, a CSV export of it (-csv) or
+an Excel workbook (-xlsx), and writes the generated template JSON. Every
+flag falls back to the environment variable shown in brackets.
 
-type Tab struct {
-	Title         string `json:"title"`
-	SubTitle      string `json:"sub_title"`
-	TemplateTabID string `json:"template_tab_id"`
-	Grids         []Grid `json:"grids"`
-}
+Instead of -sheet-id, -csv or -xlsx the input can be given as a source URI:
 
-type Grid struct {
-	Title          string      `json:"title"`
-	Position       int         `json:"position"`
-	SubTitle       string      `json:"sub_title"`
-	TemplateGridID string      `json:"template_grid_id"`
-	Styling        GridStyling `json:"styling"`
-	Charts         []Chart     `json:"charts"`
-}
+  gsheet://<spreadsheet ID>[?range=Sheet1!A4:J]
+  file://<path>.csv[?skip=3]
+  file://<path>.xlsx[?range=Sheet1!A4:J]
 
-type GridStyling struct {
-	TitleStyle    GridFontStyle `json:"titleStyle"`
-	SubTitleStyle GridFontStyle `json:"subTitleStyle"`
-}
+With -sheets the range is read from each selected worksheet in turn. Each
+worksheet gives a template named after it, written to the -output path
+with {sheet} replaced by the worksheet title in file name form, or with
+_<title> added before the extension when the path has no {sheet}; the
+-update path is expanded the same way.
 
-type GridFontStyle struct {
-	Font       string   `json:"font"`
-	Color      string   `json:"color"`
-	FontSize   int      `json:"font_size"`
-	FontFormat []string `json:"font_format"`
-}
+With -manifest the templates listed in a JSON manifest are generated, up
+to -jobs at once, followed by a summary of each. An entry names its input
+by "source" or "sheet_id" and its "output" file, and can set "range",
+"sheets", "name", "theme" and "update" (see -update); the other settings
+come from the flags. No two entries may write the same output, nor update
+from another entry's output. Relative paths are taken from the manifest
+directory:
 
-type Chart struct {
-	ChartType       string       `json:"chart_type"`
-	Source          string       `json:"source"`
-	Title           string       `json:"title"`
-	TemplateChartID string       `json:"template_chart_id"`
-	LeftMetrics     []Metric     `json:"left_metrics"`
-	RightMetrics    []Metric     `json:"right_metrics,omitempty"`
-	Dimensions      []Metric     `json:"dimensions,omitempty"`
-	GridPosition    GridPos      `json:"grid_position"`
-	Styling         ChartStyling `json:"styling"`
-}
+  {"templates": [
+    {"sheet_id": "1AbC...", "name": "Retail", "theme": "dark", "output": "retail.json"},
+    {"source": "travel.xlsx", "sheets": "*", "output": "travel_{sheet}.json"}
+  ]}
+
+The render command does the reverse, see template-generator render -h, and
+the diff command compares two templates, see template-generator diff -h.
+
+Flags:
+`
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("template-generator: ")
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "render":
+			renderMain(os.Args[2:])
+			return
+		case "diff":
+			diffMain(os.Args[2:])
+			return
+		}
+	}
 
-type ChartStyling struct {
-	Palette        int              `json:"palette"`
-	TitleStyle     ChartFontStyle   `json:"titleStyle"`
-	TableStyle     TableTypeStyle   `json:"tableStyle"`
-	LegendStyle    InsideTableStyle `json:"legendStyle"`
-	LegendPosition string           `json:"legendPosition"`
+	cfg, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	if err := run(context.Background(), cfg); err != nil {
+		log.Fatal(err)
+	}
 }
 
-type ChartFontStyle struct {
-	Font       string   `json:"font"`
-	Color      string   `json:"color"`
-	FontSize   int      `json:"fontSize"`
-	FontFormat []string `json:"fontFormat"`
-	Alignment  string   `josn:"alignment"`
+// parseFlags reads the generator flags from args, using the environment for
+// any flag that is not set. Usage and errors are printed to output.
+func parseFlags(args []string, output io.Writer) (config, error) {
+	var cfg config
+
+	fs := flag.NewFlagSet("template-generator", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usageHeader)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.Source, "source", os.Getenv("TEMPLATEGEN_SOURCE"), "source URI of the template rows [TEMPLATEGEN_SOURCE]")
+	fs.StringVar(&cfg.ManifestPath, "manifest", os.Getenv("TEMPLATEGEN_MANIFEST"), "manifest file listing the templates to generate in one run, see above [TEMPLATEGEN_MANIFEST]")
+	fs.IntVar(&cfg.Jobs, "jobs", envInt("TEMPLATEGEN_JOBS", 4), "number of manifest entries generated at once [TEMPLATEGEN_JOBS]")
+	fs.StringVar(&cfg.SheetID, "sheet-id", os.Getenv("TEMPLATEGEN_SHEET_ID"), "ID of the Google Sheet holding the template layout [TEMPLATEGEN_SHEET_ID]")
+	fs.StringVar(&cfg.CredentialsFile, "credentials", envOr("TEMPLATEGEN_CREDENTIALS", "credentials.json"), "service account credentials file [TEMPLATEGEN_CREDENTIALS]")
+	fs.StringVar(&cfg.ReadRange, "range", envOr("TEMPLATEGEN_RANGE", templategen.DefaultRange), "A1 range holding the template rows, also used for -xlsx [TEMPLATEGEN_RANGE]")
+	fs.StringVar(&cfg.Sheets, "sheets", os.Getenv("TEMPLATEGEN_SHEETS"), "comma-separated worksheet titles or glob patterns, * for all, to generate a template from each worksheet of the Google Sheet or workbook; see above [TEMPLATEGEN_SHEETS]")
+	fs.StringVar(&cfg.CSVPath, "csv", os.Getenv("TEMPLATEGEN_CSV"), "read the template rows from this CSV file instead of Google Sheets [TEMPLATEGEN_CSV]")
+	fs.IntVar(&cfg.CSVSkip, "csv-skip", envInt("TEMPLATEGEN_CSV_SKIP", templategen.DefaultHeaderRows), "number of heading rows to skip in the CSV file [TEMPLATEGEN_CSV_SKIP]")
+	fs.StringVar(&cfg.XLSXPath, "xlsx", os.Getenv("TEMPLATEGEN_XLSX"), "read the template rows from this .xlsx workbook instead of Google Sheets [TEMPLATEGEN_XLSX]")
+	fs.StringVar(&cfg.TemplateName, "name", envOr("TEMPLATEGEN_NAME", templategen.DefaultTemplateName), "template name written to the output [TEMPLATEGEN_NAME]")
+	fs.StringVar(&cfg.IDMode, "ids", envOr("TEMPLATEGEN_IDS", string(templategen.IDRandom)), "ID generation: random, or stable to derive IDs from the template name and object titles [TEMPLATEGEN_IDS]")
+	fs.StringVar(&cfg.PreviousPath, "update", os.Getenv("TEMPLATEGEN_UPDATE"), "previous template JSON, usually the -output file: objects at the same title path keep their IDs, only new ones get new IDs [TEMPLATEGEN_UPDATE]")
+	fs.BoolVar(&cfg.StrictBoardType, "strict-board-type", envBool("TEMPLATEGEN_STRICT_BOARD_TYPE", false), "take the board type only from column I, never from \"Report\" in the tab title [TEMPLATEGEN_STRICT_BOARD_TYPE]")
+	fs.BoolVar(&cfg.Header, "header", envBool("TEMPLATEGEN_HEADER", false), "map columns by the header row, the first row read; the default range and CSV skip then start one row higher [TEMPLATEGEN_HEADER]")
+	cfg.Aliases = make(aliasFlag)
+	if err := cfg.Aliases.Set(os.Getenv("TEMPLATEGEN_ALIASES")); err != nil {
+		fmt.Fprintln(fs.Output(), "TEMPLATEGEN_ALIASES:", err)
+		return config{}, err
+	}
+	fs.Var(cfg.Aliases, "alias", "extra header name for a column with -header, as `Header=Column`; repeatable or comma-separated [TEMPLATEGEN_ALIASES]")
+	fs.StringVar(&cfg.CatalogPath, "catalog", os.Getenv("TEMPLATEGEN_CATALOG"), "metric catalog (.json or .csv) used to fill in metric details by ID [TEMPLATEGEN_CATALOG]")
+	fs.BoolVar(&cfg.StrictCatalog, "strict-catalog", envBool("TEMPLATEGEN_STRICT_CATALOG", false), "fail when a metric is missing from the catalog or named differently [TEMPLATEGEN_STRICT_CATALOG]")
+	fs.BoolVar(&cfg.Layout, "layout", envBool("TEMPLATEGEN_LAYOUT", true), "fill in the grid position of every chart [TEMPLATEGEN_LAYOUT]")
+	fs.IntVar(&cfg.GridColumns, "grid-columns", envInt("TEMPLATEGEN_GRID_COLUMNS", templategen.DefaultGridColumns), "width of the dashboard grid in columns [TEMPLATEGEN_GRID_COLUMNS]")
+	fs.StringVar(&cfg.ThemePaths, "themes", os.Getenv("TEMPLATEGEN_THEMES"), "comma-separated theme files or directories of them [TEMPLATEGEN_THEMES]")
+	fs.StringVar(&cfg.Theme, "theme", os.Getenv("TEMPLATEGEN_THEME"), "theme applied to the whole template, \""+templategen.DefaultThemeName+"\" when it exists [TEMPLATEGEN_THEME]")
+	fs.StringVar(&cfg.Schema, "schema", envOr("TEMPLATEGEN_SCHEMA", strconv.Itoa(templategen.SchemaVersion)), "output schema version, or legacy (1) for the original field names [TEMPLATEGEN_SCHEMA]")
+	fs.BoolVar(&cfg.Strict, "strict", envBool("TEMPLATEGEN_STRICT", false), "fail on template rule warnings too, not only on errors [TEMPLATEGEN_STRICT]")
+	fs.BoolVar(&cfg.AllowErrors, "allow-errors", envBool("TEMPLATEGEN_ALLOW_ERRORS", false), "write the template even when it breaks template rules of error severity, which are still reported [TEMPLATEGEN_ALLOW_ERRORS]")
+	fs.BoolVar(&cfg.PrintSchema, "print-schema", false, "write the JSON Schema of the -schema version to stdout and exit")
+	fs.StringVar(&cfg.OutputPath, "output", envOr("TEMPLATEGEN_OUTPUT", "output_template.json"), "output file, or - for stdout [TEMPLATEGEN_OUTPUT]")
+
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if cfg.Header {
+		// the header sits on the row above the template rows
+		if !set["range"] && os.Getenv("TEMPLATEGEN_RANGE") == "" {
+			cfg.ReadRange = templategen.DefaultHeaderRange
+		}
+		if !set["csv-skip"] && os.Getenv("TEMPLATEGEN_CSV_SKIP") == "" {
+			cfg.CSVSkip = templategen.DefaultHeaderRows - 1
+		}
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected arguments: %v", fs.Args())
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return config{}, err
+	}
+	if cfg.CSVPath != "" && cfg.XLSXPath != "" {
+		err := errors.New("-csv and -xlsx cannot be used together")
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.ManifestPath != "" && (cfg.sourceURI() != "" || cfg.Sheets != "") {
+		err := errors.New("-manifest cannot be used with -source, -sheet-id, -csv, -xlsx or -sheets; give sheets per manifest entry")
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.ManifestPath != "" && (set["output"] || os.Getenv("TEMPLATEGEN_OUTPUT") != "" || cfg.PreviousPath != "") {
+		err := errors.New("-manifest cannot be used with -output or -update; give them per manifest entry")
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.Jobs < 1 {
+		err := fmt.Errorf("-jobs must be at least 1, got %d", cfg.Jobs)
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.Sheets != "" && cfg.OutputPath == "-" {
+		err := errors.New("-sheets writes a file per worksheet and cannot write to stdout")
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.Theme != "" && cfg.ThemePaths == "" {
+		err := errors.New("-theme needs -themes")
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.Strict && cfg.AllowErrors {
+		err := errors.New("-strict and -allow-errors cannot be used together")
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if _, err := templategen.ParseIDMode(cfg.IDMode); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if _, err := templategen.ParseSchema(cfg.Schema); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return config{}, err
+	}
+	if cfg.sourceURI() == "" && cfg.ManifestPath == "" && !cfg.PrintSchema {
+		err := errors.New("an input is required: -source, -sheet-id, -csv, -xlsx or -manifest")
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return config{}, err
+	}
+	return cfg, nil
 }
 
-type TableTypeStyle struct {
-	TableHeader  InsideTableStyle `json:"tableHeader"`
-	TableContent InsideTableStyle `json:"tableContent"`
+// sourceURI returns the source URI given by -source, or the one equivalent to
+// the -csv, -xlsx or -sheet-id flag.
+func (cfg config) sourceURI() string {
+	switch {
+	case cfg.Source != "":
+		return cfg.Source
+	case cfg.CSVPath != "":
+		return "file://" + cfg.CSVPath
+	case cfg.XLSXPath != "":
+		return "file://" + cfg.XLSXPath
+	case cfg.SheetID != "":
+		return "gsheet://" + cfg.SheetID
+	}
+	return ""
 }
 
-type InsideTableStyle struct {
-	Font     string `json:"font"`
-	FontSize int    `json:"fontSize"`
+// aliasFlag collects Header=Column pairs for -alias.
+type aliasFlag map[string]templategen.Column
+
+func (a aliasFlag) String() string {
+	var pairs []string
+	for header, column := range a {
+		pairs = append(pairs, header+"="+string(column))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
 }
 
-type Metric struct {
-	ID                string `json:"id"`
-	Name              string `json:"name"`
-	Path              string `json:"path"`
-	Type              string `json:"type"`
-	Group             string `json:"group"`
-	Category          string `json:"category"`
-	DataType          string `json:"dataType"`
-	MetricType        string `json:"metricType"`
-	Description       string `json:"description"`
-	DivideByMillion   bool   `json:"divideByMillion"`
-	AggregationMethod string `json:"aggregationMethod"`
+func (a aliasFlag) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		header, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("alias %q is not of the form Header=Column", pair)
+		}
+		column, err := templategen.ParseColumn(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		a[strings.TrimSpace(header)] = column
+	}
+	return nil
 }
 
-type GridPos struct {
-	H    int `json:"h"`
-	W    int `json:"w"`
-	X    int `json:"x"`
-	Y    int `json:"y"`
-	MaxH int `json:"maxH"`
-	MinH int `json:"minH"`
-	MinW int `json:"minW"`
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
 }
 
-func main() {
-	// Google Sheet ID and credentials
-	sheetID := "1ktTQ1scbWywG8oJZoLuqvHrZXZhtHwv3QtU92hv021w"
-	credentialsFile := "credentials.json"
+// envInt is envOr for integer settings; an unparsable value falls back too.
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
 
-	// Initialize Sheets service
-	sheetsService, err := sheets.NewService(context.Background(), option.WithCredentialsFile(credentialsFile))
-	if err != nil {
-		log.Fatalf("Unable to create Sheets service: %v", err)
+// envBool is envOr for boolean settings such as "1" or "true"; an
+// unparsable value falls back too.
+func envBool(key string, fallback bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
 	}
+	return fallback
+}
 
-	// Initialize GlobalTemplateConfig
-	finalTemplateConfig := GlobalTemplateConfig{
-		Global: Global{
-			TemplateID:   uuid.New().String(),
-			TemplateName: "Generated Template",
-		},
+func run(ctx context.Context, cfg config) error {
+	schema, _ := templategen.ParseSchema(cfg.Schema)
+	if cfg.PrintSchema {
+		return printSchema(os.Stdout, schema)
 	}
 
-	// Read data from Google Sheet
-	readRange := "Sheet1!A4:J" // Adjust range if necessary
-	data, err := sheetsService.Spreadsheets.Values.Get(sheetID, readRange).Do()
+	g, err := newGenerator(cfg)
 	if err != nil {
-		log.Fatalf("Unable to retrieve data from Google Sheet: %v", err)
+		return err
 	}
+	if cfg.ManifestPath != "" {
+		return runBatch(ctx, g, cfg.ManifestPath, os.Stdout)
+	}
+	return g.run(ctx, log.Default())
+}
 
-	var dashboardTemplateConfigArray []TemplateConfigs
-	dashboardTemplateConfigNumber := -1
-	var reportTemplateConfigArray []TemplateConfigs
-	reportTemplateConfigNumber := -1
-	// var dashboardTabsArray []Tab
-	// dashboardTabNumber := 0
-	// var reportTabsArray []Tab
-	// reportTabNumber := 0
-
-	currentDashboardTemplateConfig := &TemplateConfigs{}
-	currentReportTemplateConfig := &TemplateConfigs{}
-	currentTab := &Tab{}
-	currentGrid := &Grid{}
-	currentChart := &Chart{}
+// generator turns template rows into template files with the settings of a
+// run, loading themes and the catalog once for all of them.
+type generator struct {
+	cfg    config
+	parser templategen.Parser
+	schema int
+}
 
-	boardOfType := ""
+// job is a template for a generator to write.
+type job struct {
+	// name is the template name
+	name string
+	// output is the path to write the template to, previous that of the
+	// template whose IDs to keep, if any
+	output, previous string
+	log              *log.Logger
+}
 
-	// Parse sheet data
-	for _, row := range data.Values {
-		if len(row) == 0 {
-			continue
+func newGenerator(cfg config) (*generator, error) {
+	ids, _ := templategen.ParseIDMode(cfg.IDMode)
+	schema, _ := templategen.ParseSchema(cfg.Schema)
+	g := &generator{
+		cfg: cfg,
+		parser: templategen.Parser{
+			IDs:             ids,
+			StrictBoardType: cfg.StrictBoardType,
+			Header:          cfg.Header,
+			Aliases:         cfg.Aliases,
+		},
+		schema: schema,
+	}
+	if cfg.Layout {
+		g.parser.GridLayout = &templategen.GridLayout{Columns: cfg.GridColumns}
+	}
+	if cfg.ThemePaths != "" {
+		themes, err := templategen.LoadThemes(strings.Split(cfg.ThemePaths, ",")...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load themes: %w", err)
 		}
-
-		//intCheck := 0
-
-		if row[0] != "" {
-			if boardOfType == "" { //this condition is for starting the process and setting the context to the first board type found
-				if !strings.Contains(fmt.Sprint(row[0]), "Report") {
-					boardOfType = "DASHBOARD"
-					currentDashboardTemplateConfig = &TemplateConfigs{
-						BoardType:        boardOfType,
-						TemplateConfigID: uuid.New().String(),
-						TemplateType:     "TAB_GRID_CHART",
-					}
-					dashboardTemplateConfigArray = append(dashboardTemplateConfigArray, *currentDashboardTemplateConfig)
-					dashboardTemplateConfigNumber++
-				} else if strings.Contains(fmt.Sprint(row[0]), "Report") {
-					boardOfType = "REPORT"
-					currentReportTemplateConfig = &TemplateConfigs{
-						BoardType:        boardOfType,
-						TemplateConfigID: uuid.New().String(),
-						TemplateType:     "TAB_CHART",
-					}
-					reportTemplateConfigArray = append(reportTemplateConfigArray, *currentReportTemplateConfig)
-					reportTemplateConfigNumber++
-				}
-
-			} else if boardOfType == "DASHBOARD" && !strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to continue the dashboard context
-				//we append the tab without changing the context
-				fmt.Println("Same DASHBOARD Template Continuation")
-				fmt.Println("Appending Dashboard Tab")
-				dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs = append(dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs, *currentTab)
-				//dashboardTabNumber++
-			} else if boardOfType == "REPORT" && strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to continue the report context
-				//we append the tab without changing the context
-				fmt.Println("Same REPORT Template Continuation")
-				fmt.Println("Appending Report Tab")
-				reportTemplateConfigArray[reportTemplateConfigNumber].Tabs = append(reportTemplateConfigArray[reportTemplateConfigNumber].Tabs, *currentTab)
-				//reportTabNumber++
-			} else if boardOfType == "DASHBOARD" && strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to change the dashboard context to report context
-				//we need to change the context here
-				fmt.Println("Changing from DASHBOARD Template to REPORT Template")
-				fmt.Println("Appending Dashboard Tab")
-				dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs = append(dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs, *currentTab)
-				//changing the context here
-				boardOfType = "REPORT"
-				currentReportTemplateConfig = &TemplateConfigs{
-					BoardType:        boardOfType,
-					TemplateConfigID: uuid.New().String(),
-					TemplateType:     "TAB_CHART",
-				}
-				reportTemplateConfigArray = append(reportTemplateConfigArray, *currentReportTemplateConfig)
-				reportTemplateConfigNumber++
-			} else if boardOfType == "REPORT" && !strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to change the report context to dashboard context
-				//we need to change the context here
-				fmt.Println("Changing from REPORT Template to DASHBOARD Template")
-				fmt.Println("Appending Report Tab")
-				reportTemplateConfigArray[reportTemplateConfigNumber].Tabs = append(reportTemplateConfigArray[reportTemplateConfigNumber].Tabs, *currentTab)
-				//changing the context here
-				boardOfType = "DASHBOARD"
-				currentDashboardTemplateConfig = &TemplateConfigs{
-					BoardType:        boardOfType,
-					TemplateConfigID: uuid.New().String(),
-					TemplateType:     "TAB_GRID_CHART",
-				}
-				dashboardTemplateConfigArray = append(dashboardTemplateConfigArray, *currentDashboardTemplateConfig)
-				dashboardTemplateConfigNumber++
-			}
-
-			//creating a new tab whenever we come accross it in column 1
-			newTab := &Tab{
-				Title:         fmt.Sprint(row[0]),
-				TemplateTabID: uuid.New().String(),
-			}
-			currentTab = newTab
-
+		g.parser.Themes, g.parser.Theme = themes, cfg.Theme
+	}
+	if cfg.CatalogPath != "" {
+		catalog, err := templategen.LoadCatalog(cfg.CatalogPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load metric catalog: %w", err)
 		}
+		g.parser.Catalog = catalog
+	}
+	return g, nil
+}
 
-		//handling the grids when we come across it
+// run generates the template, or with -sheets the templates, of the source
+// of g.cfg, logging to logger.
+func (g *generator) run(ctx context.Context, logger *log.Logger) error {
+	source, err := templategen.OpenSource(ctx, g.cfg.sourceURI(), templategen.SourceOptions{
+		CredentialsFile: g.cfg.CredentialsFile,
+		Range:           g.cfg.ReadRange,
+		HeaderRows:      g.cfg.CSVSkip,
+	})
+	if err != nil {
+		return err
+	}
+	if g.cfg.Sheets != "" {
+		return g.runSheets(ctx, source, logger)
+	}
+	rows, err := source.ReadRows(ctx)
+	if err != nil {
+		return err
+	}
+	return g.generate(rows, job{
+		name:     g.cfg.TemplateName,
+		output:   g.cfg.OutputPath,
+		previous: g.cfg.PreviousPath,
+		log:      logger,
+	})
+}
 
-		//when we get a new grid at the start
-		if len(row) < 2 {
-			continue
+// generate parses rows into a template and writes it as given by j.
+func (g *generator) generate(rows templategen.Rows, j job) error {
+	parser := g.parser
+	parser.TemplateName = j.name
+	if j.previous != "" {
+		previous, err := readTemplate(j.previous)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			j.log.Printf("update: no previous template at %s, every object gets a new ID", j.previous)
+		case err != nil:
+			return fmt.Errorf("unable to read the previous template: %w", err)
+		default:
+			parser.Previous = &previous
 		}
-		if row[1] != "" {
-
-			currentTab.Grids = append(currentTab.Grids, *currentGrid)
-
-			newGrid := &Grid{
-				Title:          fmt.Sprint(row[1]),
-				TemplateGridID: uuid.New().String(),
-			}
-			currentGrid = newGrid
-			//handling charts
-			if len(row) < 3 {
-				continue
-			}
-			if row[2] != "" {
-
-				currentGrid.Charts = append(currentGrid.Charts, *currentChart)
-
-				newChart := &Chart{
-					TemplateChartID: uuid.New().String(),
-					ChartType:       fmt.Sprint(row[2]),
-					Title:           fmt.Sprint(row[3]),
-				}
-				currentChart = newChart
-				// handling first chart dimension
-				if row[4] != "" {
-					dimension := &Metric{
-						Name: fmt.Sprint(row[4]),
-						ID:   fmt.Sprint(row[5]),
-					}
-					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-				}
-
-				//handling chart first left/right metric
-				if row[6] != "" {
-					metric := &Metric{
-						Name: fmt.Sprint(row[6]),
-						ID:   fmt.Sprint(row[7]),
-					}
-					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-				}
-
-			} else if row[2] == "" {
-				if row[4] != "" {
-					dimension := &Metric{
-						Name: fmt.Sprint(row[4]),
-						ID:   fmt.Sprint(row[5]),
-					}
-					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-				}
-				if row[6] != "" && currentChart.ChartType == "Line" {
-					metric := &Metric{
-						Name: fmt.Sprint(row[6]),
-						ID:   fmt.Sprint(row[7]),
-					}
-					currentChart.RightMetrics = append(currentChart.RightMetrics, *metric)
-
-				} else if row[6] != "" && currentChart.ChartType != "Line" {
-					metric := &Metric{
-						Name: fmt.Sprint(row[6]),
-						ID:   fmt.Sprint(row[7]),
-					}
-					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-				}
-			}
+	}
+	catalogProblems := 0
+	parser.OnCatalogProblem = func(problem templategen.CatalogProblem) {
+		j.log.Printf("catalog: %s", problem)
+		catalogProblems++
+	}
+	finalTemplateConfig, locations, err := parser.ParseRowsWithLocations(rows)
+	if err != nil {
+		return fmt.Errorf("unable to parse template rows: %w", err)
+	}
 
-		} else if row[1] == "" {
-			//handling charts
-			if row[2] != "" {
-
-				currentGrid.Charts = append(currentGrid.Charts, *currentChart)
-
-				newChart := &Chart{
-					TemplateChartID: uuid.New().String(),
-					ChartType:       fmt.Sprint(row[2]),
-					Title:           fmt.Sprint(row[3]),
-				}
-				currentChart = newChart
-				// handling first chart dimension
-				if row[4] != "" {
-					dimension := &Metric{
-						Name: fmt.Sprint(row[4]),
-						ID:   fmt.Sprint(row[5]),
-					}
-					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-				}
-
-				//handling chart first left/right metric
-				if row[6] != "" {
-					metric := &Metric{
-						Name: fmt.Sprint(row[6]),
-						ID:   fmt.Sprint(row[7]),
-					}
-					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-				}
-
-			} else if row[2] == "" {
-				if row[4] != "" {
-					dimension := &Metric{
-						Name: fmt.Sprint(row[4]),
-						ID:   fmt.Sprint(row[5]),
-					}
-					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-				}
-				if row[6] != "" && currentChart.ChartType == "Line" {
-					metric := &Metric{
-						Name: fmt.Sprint(row[6]),
-						ID:   fmt.Sprint(row[7]),
-					}
-					currentChart.RightMetrics = append(currentChart.RightMetrics, *metric)
-
-				} else if row[6] != "" && currentChart.ChartType != "Line" {
-					metric := &Metric{
-						Name: fmt.Sprint(row[6]),
-						ID:   fmt.Sprint(row[7]),
-					}
-					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-				}
+	if parser.Previous != nil {
+		// the boards, tabs, grids and charts added or removed since are
+		// those whose IDs are new or gone
+		for _, c := range templategen.Diff(*parser.Previous, finalTemplateConfig) {
+			idChanged := c.Kind == templategen.ChangeAdded || c.Kind == templategen.ChangeRemoved
+			if idChanged && c.Object != "metric" && c.Object != "dimension" {
+				j.log.Printf("update: %s", c)
 			}
 		}
+	}
 
-		//intCheck++
-
+	if g.cfg.StrictCatalog && catalogProblems > 0 {
+		return fmt.Errorf("%d metrics do not match the catalog", catalogProblems)
 	}
 
-	//we added this piece of code at the end to add the last tab which may be a report or a dashboard
-	if boardOfType == "DASHBOARD" { // appending the last tab with respect to context
-		fmt.Println("Appending Last Tab which is a DASHBOARD")
-		dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs = append(dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs, *currentTab)
-	} else if boardOfType == "REPORT" { // appending the last tab with respect to context
-		fmt.Println("Appending Last Tab which is a REPORT")
-		reportTemplateConfigArray[reportTemplateConfigNumber].Tabs = append(reportTemplateConfigArray[reportTemplateConfigNumber].Tabs, *currentTab)
+	issues := templategen.Validate(finalTemplateConfig, locations)
+	for _, issue := range issues {
+		j.log.Print(issue)
+	}
+	if n := len(issues.Errors()); n > 0 && !g.cfg.AllowErrors {
+		return fmt.Errorf("template has %d errors", n)
+	}
+	if g.cfg.Strict && len(issues) > 0 {
+		return fmt.Errorf("template has %d warnings", len(issues))
 	}
 
-	finalTemplateConfig.Global.TemplateConfigs = append(finalTemplateConfig.Global.TemplateConfigs, dashboardTemplateConfigArray...)
-	finalTemplateConfig.Global.TemplateConfigs = append(finalTemplateConfig.Global.TemplateConfigs, reportTemplateConfigArray...)
+	if err := writeTemplate(j.output, finalTemplateConfig, g.schema); err != nil {
+		return err
+	}
+	if j.output != "-" {
+		j.log.Printf("template JSON written to %s", j.output)
+	}
+	return nil
+}
 
-	// Write JSON to file
-	outputFile, err := os.Create("output_template.json")
+// writeTemplate writes cfg as JSON of the given schema version to path, or
+// to stdout when path is "-". The JSON is checked against the schema first
+// and nothing is written when it does not conform.
+func writeTemplate(path string, cfg templategen.GlobalTemplateConfig, schema int) error {
+	data, err := templategen.MarshalTemplate(cfg, schema)
 	if err != nil {
-		log.Fatalf("Unable to create output file: %v", err)
+		return fmt.Errorf("unable to write JSON: %w", err)
 	}
-	defer outputFile.Close()
-
-	encoder := json.NewEncoder(outputFile)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(finalTemplateConfig); err != nil {
-		log.Fatalf("Unable to write JSON to file: %v", err)
+	if err := templategen.ValidateTemplate(data, schema); err != nil {
+		return fmt.Errorf("generated template does not match schema version %d: %w", schema, err)
 	}
-
-	fmt.Println("Template JSON generated successfully!")
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("unable to write output file: %w", err)
+	}
+	return nil
 }
 
-// above is the old logic
-
-// func main() {
-// 	// Google Sheet ID and credentials
-// 	sheetID := "1ktTQ1scbWywG8oJZoLuqvHrZXZhtHwv3QtU92hv021w"
-// 	credentialsFile := "credentials.json"
-
-// 	// Initialize Sheets service
-// 	sheetsService, err := sheets.NewService(context.Background(), option.WithCredentialsFile(credentialsFile))
-// 	if err != nil {
-// 		log.Fatalf("Unable to create Sheets service: %v", err)
-// 	}
-
-// 	// Initialize GlobalTemplateConfig
-// 	finalTemplateConfig := GlobalTemplateConfig{
-// 		Global: Global{
-// 			TemplateID:   uuid.New().String(),
-// 			TemplateName: "Generated Template",
-// 		},
-// 	}
-
-// 	// Read data from Google Sheet
-// 	readRange := "Sheet1!A4:J" // Adjust range if necessary
-// 	data, err := sheetsService.Spreadsheets.Values.Get(sheetID, readRange).Do()
-// 	if err != nil {
-// 		log.Fatalf("Unable to retrieve data from Google Sheet: %v", err)
-// 	}
-
-// 	var dashboardTemplateConfigArray []TemplateConfigs
-// 	dashboardTemplateConfigNumber := -1
-// 	var reportTemplateConfigArray []TemplateConfigs
-// 	reportTemplateConfigNumber := -1
-// 	// var dashboardTabsArray []Tab
-// 	// dashboardTabNumber := 0
-// 	// var reportTabsArray []Tab
-// 	// reportTabNumber := 0
-
-// 	currentDashboardTemplateConfig := &TemplateConfigs{}
-// 	currentReportTemplateConfig := &TemplateConfigs{}
-// 	currentTab := &Tab{}
-// 	currentGrid := &Grid{}
-// 	currentChart := &Chart{}
-
-// 	boardOfType := ""
-
-// 	// 	var let i = 0;
-// 	// for (i; i < len(rows); i++) {
-// 	//     if (row[i][0] != "") {
-// 	//         tab = []
-// 	//         let j = i;
-// 	//         for (j; j < len(rows); j++) {
-// 	//             if (row[j][1] != "") {
-// 	//                 grid = []
-// 	//                 for (k = j; k < len(rows); k++) {
-// 	//                     if (row[k][2] != "" && row[k][0] == "" && row[k][1] = "") {
-// 	//                         grid.charts.append(chart)
-// 	//                     } else {
-// 	//                         j = k
-// 	//                         // new gird or tab to be created
-// 	//                     }
-// 	//                 }
-// 	//             } else {
-// 	//                 tab.grid.append(grid)
-// 	//             }
-// 	//         } else {
-// 	//             config.tabs.append(tab)
-// 	//         }
-// 	//     }
-// 	// }
-
-// 	spreadSheet := data.Values
-
-// 	// Parse sheet data
-// 	for i := 0; i < len(spreadSheet); i++ {
-// 		if len(spreadSheet[i]) == 0 {
-// 			continue
-// 		}
-
-// 		if boardOfType == "" { //this condition is for starting the process and setting the context to the first board type found
-// 			if !strings.Contains(fmt.Sprint(row[0]), "Report") {
-// 				boardOfType = "DASHBOARD"
-// 				currentDashboardTemplateConfig = &TemplateConfigs{
-// 					BoardType:        boardOfType,
-// 					TemplateConfigID: uuid.New().String(),
-// 					TemplateType:     "TAB_GRID_CHART",
-// 				}
-// 				dashboardTemplateConfigArray = append(dashboardTemplateConfigArray, *currentDashboardTemplateConfig)
-// 				dashboardTemplateConfigNumber++
-// 			} else if strings.Contains(fmt.Sprint(row[0]), "Report") {
-// 				boardOfType = "REPORT"
-// 				currentReportTemplateConfig = &TemplateConfigs{
-// 					BoardType:        boardOfType,
-// 					TemplateConfigID: uuid.New().String(),
-// 					TemplateType:     "TAB_CHART",
-// 				}
-// 				reportTemplateConfigArray = append(reportTemplateConfigArray, *currentReportTemplateConfig)
-// 				reportTemplateConfigNumber++
-// 			}
-
-// 		} else if boardOfType == "DASHBOARD" && !strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to continue the dashboard context
-// 			//we append the tab without changing the context
-// 			fmt.Println("Same DASHBOARD Template Continuation")
-// 			fmt.Println("Appending Dashboard Tab")
-// 			dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs = append(dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs, *currentTab)
-// 			//dashboardTabNumber++
-// 		} else if boardOfType == "REPORT" && strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to continue the report context
-// 			//we append the tab without changing the context
-// 			fmt.Println("Same REPORT Template Continuation")
-// 			fmt.Println("Appending Report Tab")
-// 			reportTemplateConfigArray[reportTemplateConfigNumber].Tabs = append(reportTemplateConfigArray[reportTemplateConfigNumber].Tabs, *currentTab)
-// 			//reportTabNumber++
-// 		} else if boardOfType == "DASHBOARD" && strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to change the dashboard context to report context
-// 			//we need to change the context here
-// 			fmt.Println("Changing from DASHBOARD Template to REPORT Template")
-// 			fmt.Println("Appending Dashboard Tab")
-// 			dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs = append(dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs, *currentTab)
-// 			//changing the context here
-// 			boardOfType = "REPORT"
-// 			currentReportTemplateConfig = &TemplateConfigs{
-// 				BoardType:        boardOfType,
-// 				TemplateConfigID: uuid.New().String(),
-// 				TemplateType:     "TAB_CHART",
-// 			}
-// 			reportTemplateConfigArray = append(reportTemplateConfigArray, *currentReportTemplateConfig)
-// 			reportTemplateConfigNumber++
-// 		} else if boardOfType == "REPORT" && !strings.Contains(fmt.Sprint(row[0]), "Report") { // this check is to change the report context to dashboard context
-// 			//we need to change the context here
-// 			fmt.Println("Changing from REPORT Template to DASHBOARD Template")
-// 			fmt.Println("Appending Report Tab")
-// 			reportTemplateConfigArray[reportTemplateConfigNumber].Tabs = append(reportTemplateConfigArray[reportTemplateConfigNumber].Tabs, *currentTab)
-// 			//changing the context here
-// 			boardOfType = "DASHBOARD"
-// 			currentDashboardTemplateConfig = &TemplateConfigs{
-// 				BoardType:        boardOfType,
-// 				TemplateConfigID: uuid.New().String(),
-// 				TemplateType:     "TAB_GRID_CHART",
-// 			}
-// 			dashboardTemplateConfigArray = append(dashboardTemplateConfigArray, *currentDashboardTemplateConfig)
-// 			dashboardTemplateConfigNumber++
-// 		}
-
-// 		//handling the grids when we come across it
-
-// 		//when we get a new grid at the start
-// 		if len(row) < 2 {
-// 			continue
-// 		}
-// 		if row[1] != "" {
-
-// 			currentTab.Grids = append(currentTab.Grids, *currentGrid)
-
-// 			newGrid := &Grid{
-// 				Title:          fmt.Sprint(row[1]),
-// 				TemplateGridID: uuid.New().String(),
-// 			}
-// 			currentGrid = newGrid
-// 			//handling charts
-// 			if len(row) < 3 {
-// 				continue
-// 			}
-// 			if row[2] != "" {
-
-// 				currentGrid.Charts = append(currentGrid.Charts, *currentChart)
-
-// 				newChart := &Chart{
-// 					TemplateChartID: uuid.New().String(),
-// 					ChartType:       fmt.Sprint(row[2]),
-// 					Title:           fmt.Sprint(row[3]),
-// 				}
-// 				currentChart = newChart
-// 				// handling first chart dimension
-// 				if row[4] != "" {
-// 					dimension := &Metric{
-// 						Name: fmt.Sprint(row[4]),
-// 						ID:   fmt.Sprint(row[5]),
-// 					}
-// 					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-// 				}
-
-// 				//handling chart first left/right metric
-// 				if row[6] != "" {
-// 					metric := &Metric{
-// 						Name: fmt.Sprint(row[6]),
-// 						ID:   fmt.Sprint(row[7]),
-// 					}
-// 					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-// 				}
-
-// 			} else if row[2] == "" {
-// 				if row[4] != "" {
-// 					dimension := &Metric{
-// 						Name: fmt.Sprint(row[4]),
-// 						ID:   fmt.Sprint(row[5]),
-// 					}
-// 					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-// 				}
-// 				if row[6] != "" && currentChart.ChartType == "Line" {
-// 					metric := &Metric{
-// 						Name: fmt.Sprint(row[6]),
-// 						ID:   fmt.Sprint(row[7]),
-// 					}
-// 					currentChart.RightMetrics = append(currentChart.RightMetrics, *metric)
-
-// 				} else if row[6] != "" && currentChart.ChartType != "Line" {
-// 					metric := &Metric{
-// 						Name: fmt.Sprint(row[6]),
-// 						ID:   fmt.Sprint(row[7]),
-// 					}
-// 					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-// 				}
-// 			}
-
-// 		} else if row[1] == "" {
-// 			//handling charts
-// 			if row[2] != "" {
-
-// 				currentGrid.Charts = append(currentGrid.Charts, *currentChart)
-
-// 				newChart := &Chart{
-// 					TemplateChartID: uuid.New().String(),
-// 					ChartType:       fmt.Sprint(row[2]),
-// 					Title:           fmt.Sprint(row[3]),
-// 				}
-// 				currentChart = newChart
-// 				// handling first chart dimension
-// 				if row[4] != "" {
-// 					dimension := &Metric{
-// 						Name: fmt.Sprint(row[4]),
-// 						ID:   fmt.Sprint(row[5]),
-// 					}
-// 					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-// 				}
-
-// 				//handling chart first left/right metric
-// 				if row[6] != "" {
-// 					metric := &Metric{
-// 						Name: fmt.Sprint(row[6]),
-// 						ID:   fmt.Sprint(row[7]),
-// 					}
-// 					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-// 				}
-
-// 			} else if row[2] == "" {
-// 				if row[4] != "" {
-// 					dimension := &Metric{
-// 						Name: fmt.Sprint(row[4]),
-// 						ID:   fmt.Sprint(row[5]),
-// 					}
-// 					currentChart.Dimensions = append(currentChart.Dimensions, *dimension)
-// 				}
-// 				if row[6] != "" && currentChart.ChartType == "Line" {
-// 					metric := &Metric{
-// 						Name: fmt.Sprint(row[6]),
-// 						ID:   fmt.Sprint(row[7]),
-// 					}
-// 					currentChart.RightMetrics = append(currentChart.RightMetrics, *metric)
-
-// 				} else if row[6] != "" && currentChart.ChartType != "Line" {
-// 					metric := &Metric{
-// 						Name: fmt.Sprint(row[6]),
-// 						ID:   fmt.Sprint(row[7]),
-// 					}
-// 					currentChart.LeftMetrics = append(currentChart.LeftMetrics, *metric)
-// 				}
-// 			}
-// 		}
-
-// 		//intCheck++
-
-// 	}
-
-// 	//we added this piece of code at the end to add the last tab which may be a report or a dashboard
-// 	if boardOfType == "DASHBOARD" { // appending the last tab with respect to context
-// 		fmt.Println("Appending Last Tab which is a DASHBOARD")
-// 		dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs = append(dashboardTemplateConfigArray[dashboardTemplateConfigNumber].Tabs, *currentTab)
-// 	} else if boardOfType == "REPORT" { // appending the last tab with respect to context
-// 		fmt.Println("Appending Last Tab which is a REPORT")
-// 		reportTemplateConfigArray[reportTemplateConfigNumber].Tabs = append(reportTemplateConfigArray[reportTemplateConfigNumber].Tabs, *currentTab)
-// 	}
-
-// 	finalTemplateConfig.Global.TemplateConfigs = append(finalTemplateConfig.Global.TemplateConfigs, dashboardTemplateConfigArray...)
-// 	finalTemplateConfig.Global.TemplateConfigs = append(finalTemplateConfig.Global.TemplateConfigs, reportTemplateConfigArray...)
-
-// 	// Write JSON to file
-// 	outputFile, err := os.Create("output_template.json")
-// 	if err != nil {
-// 		log.Fatalf("Unable to create output file: %v", err)
-// 	}
-// 	defer outputFile.Close()
-
-// 	encoder := json.NewEncoder(outputFile)
-// 	encoder.SetIndent("", "  ")
-// 	if err := encoder.Encode(finalTemplateConfig); err != nil {
-// 		log.Fatalf("Unable to write JSON to file: %v", err)
-// 	}
-
-// 	fmt.Println("Template JSON generated successfully!")
-// }
+// printSchema writes the JSON Schema of the given version to w.
+func printSchema(w io.Writer, schema int) error {
+	s, err := templategen.TemplateSchema(schema)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
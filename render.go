@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/surya-pixis/template-generator/templategen"
+)
+
+// renderConfig holds the command-line settings of the render command.
+type renderConfig struct {
+	InputPath       string
+	OutputURI       string
+	CredentialsFile string
+	WriteRange      string
+	Layout          bool
+	GridColumns     int
+	ThemePaths      string
+	Theme           string
+}
+
+const renderUsageHeader = `Usage: template-generator render [flags]
+
+Reads a template JSON, e.g. one fixed by hand after generation, and writes
+it back as template sheet rows: a header row, then the template rows in the
+layout the generator reads with -header. Every flag falls back to the
+environment variable shown in brackets.
+
+Styling is not written, as the generator takes it from themes: give -themes
+and -theme as for generating, and the grids and charts whose styling differs
+from that theme are reported.
+
+The output is a CSV file, an Excel workbook or a Google Sheet:
+
+  file://<path>.csv
+  file://<path>.xlsx[?range=Sheet1!A3]
+  gsheet://<spreadsheet ID>[?range=Sheet1!A3]
+
+Flags:
+`
+
+func renderMain(args []string) {
+	cfg, err := parseRenderFlags(args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if err := runRender(context.Background(), cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseRenderFlags reads the render flags from args, like parseFlags.
+func parseRenderFlags(args []string, output io.Writer) (renderConfig, error) {
+	var cfg renderConfig
+
+	fs := flag.NewFlagSet("template-generator render", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), renderUsageHeader)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.InputPath, "input", envOr("TEMPLATEGEN_INPUT", "output_template.json"), "template JSON to render, or - for stdin [TEMPLATEGEN_INPUT]")
+	fs.StringVar(&cfg.OutputURI, "output", os.Getenv("TEMPLATEGEN_RENDER_OUTPUT"), "CSV file, XLSX file or gsheet:// URI to write the rows to [TEMPLATEGEN_RENDER_OUTPUT]")
+	fs.StringVar(&cfg.CredentialsFile, "credentials", envOr("TEMPLATEGEN_CREDENTIALS", "credentials.json"), "service account credentials file [TEMPLATEGEN_CREDENTIALS]")
+	fs.StringVar(&cfg.WriteRange, "range", envOr("TEMPLATEGEN_RENDER_RANGE", templategen.DefaultHeaderRange), "A1 range whose top-left cell gets the header row, for XLSX and Google Sheets [TEMPLATEGEN_RENDER_RANGE]")
+	fs.BoolVar(&cfg.Layout, "layout", envBool("TEMPLATEGEN_LAYOUT", true), "leave out chart positions the generator lays out the same way itself [TEMPLATEGEN_LAYOUT]")
+	fs.IntVar(&cfg.GridColumns, "grid-columns", envInt("TEMPLATEGEN_GRID_COLUMNS", templategen.DefaultGridColumns), "width of the dashboard grid in columns [TEMPLATEGEN_GRID_COLUMNS]")
+	fs.StringVar(&cfg.ThemePaths, "themes", os.Getenv("TEMPLATEGEN_THEMES"), "comma-separated theme files or directories of them; styling other than the theme's is reported [TEMPLATEGEN_THEMES]")
+	fs.StringVar(&cfg.Theme, "theme", os.Getenv("TEMPLATEGEN_THEME"), "theme the rows will be generated with, \""+templategen.DefaultThemeName+"\" when it exists [TEMPLATEGEN_THEME]")
+
+	if err := fs.Parse(args); err != nil {
+		return renderConfig{}, err
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected arguments: %v", fs.Args())
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return renderConfig{}, err
+	}
+	if cfg.OutputURI == "" {
+		err := errors.New("an output is required: -output")
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return renderConfig{}, err
+	}
+	if cfg.Theme != "" && cfg.ThemePaths == "" {
+		err := errors.New("-theme needs -themes")
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return renderConfig{}, err
+	}
+	return cfg, nil
+}
+
+func runRender(ctx context.Context, cfg renderConfig) error {
+	template, err := readTemplate(cfg.InputPath)
+	if err != nil {
+		return err
+	}
+
+	renderer := &templategen.Renderer{Theme: cfg.Theme}
+	if cfg.ThemePaths != "" {
+		if renderer.Themes, err = templategen.LoadThemes(strings.Split(cfg.ThemePaths, ",")...); err != nil {
+			return fmt.Errorf("unable to load themes: %w", err)
+		}
+	}
+	if cfg.Layout {
+		renderer.GridLayout = &templategen.GridLayout{Columns: cfg.GridColumns}
+	}
+	rows, issues := renderer.Render(template)
+	for _, issue := range issues {
+		log.Print(issue)
+	}
+
+	sink, err := templategen.OpenSink(ctx, cfg.OutputURI, templategen.SourceOptions{
+		CredentialsFile: cfg.CredentialsFile,
+		Range:           cfg.WriteRange,
+	})
+	if err != nil {
+		return err
+	}
+	if err := sink.WriteRows(ctx, rows); err != nil {
+		return err
+	}
+	// rows written elsewhere are missed by the default range
+	hint := "-header"
+	at, _ := templategen.ParseRange(rows.Range())
+	def, _ := templategen.ParseRange(templategen.DefaultHeaderRange)
+	if at.Sheet != def.Sheet || at.StartRow != def.StartRow || at.StartCol != def.StartCol || at.EndCol > def.EndCol {
+		hint += " -range " + rows.Range()
+	}
+	fmt.Fprintf(os.Stderr, "Template rows written to %s; read them back with %s\n", cfg.OutputURI, hint)
+	return nil
+}
+
+// readTemplate reads a template JSON of any schema version from path, or
+// from stdin when path is "-".
+func readTemplate(path string) (templategen.GlobalTemplateConfig, error) {
+	if path == "-" {
+		return templategen.ReadTemplate(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return templategen.GlobalTemplateConfig{}, err
+	}
+	defer f.Close()
+	template, err := templategen.ReadTemplate(f)
+	if err != nil {
+		return templategen.GlobalTemplateConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return template, nil
+}
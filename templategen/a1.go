@@ -0,0 +1,139 @@
+package templategen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CellRange is a parsed A1 range such as "Sheet1!A4:J". Rows and columns are
+// 1-based; an EndRow or EndCol of 0 means the range is open in that direction.
+type CellRange struct {
+	Sheet    string
+	StartCol int
+	StartRow int
+	EndCol   int
+	EndRow   int
+}
+
+// ParseRange parses an A1 range with an optional sheet name, e.g. "A4:J",
+// "Sheet1!A4:J200" or "'Q1 Report'!B2:K".
+func ParseRange(s string) (CellRange, error) {
+	var r CellRange
+	ref := s
+	if i := strings.LastIndex(s, "!"); i >= 0 {
+		r.Sheet = s[:i]
+		if len(r.Sheet) >= 2 && r.Sheet[0] == '\'' && r.Sheet[len(r.Sheet)-1] == '\'' {
+			r.Sheet = strings.ReplaceAll(r.Sheet[1:len(r.Sheet)-1], "''", "'")
+		}
+		ref = s[i+1:]
+	}
+
+	start, end, hasEnd := strings.Cut(ref, ":")
+	var err error
+	if r.StartCol, r.StartRow, err = parseCellRef(start); err != nil || r.StartCol == 0 {
+		return CellRange{}, fmt.Errorf("invalid range %q", s)
+	}
+	if r.StartRow == 0 {
+		r.StartRow = 1
+	}
+	if hasEnd {
+		if r.EndCol, r.EndRow, err = parseCellRef(end); err != nil {
+			return CellRange{}, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	return r, nil
+}
+
+// String formats r back into A1 notation.
+func (r CellRange) String() string {
+	var b strings.Builder
+	if r.Sheet != "" {
+		b.WriteString(quoteSheet(r.Sheet))
+		b.WriteByte('!')
+	}
+	b.WriteString(ColumnName(r.StartCol))
+	b.WriteString(strconv.Itoa(r.StartRow))
+	if r.EndCol > 0 || r.EndRow > 0 {
+		b.WriteByte(':')
+		if r.EndCol > 0 {
+			b.WriteString(ColumnName(r.EndCol))
+		}
+		if r.EndRow > 0 {
+			b.WriteString(strconv.Itoa(r.EndRow))
+		}
+	}
+	return b.String()
+}
+
+// parseCellRef splits a reference like "J", "4" or "J4" into column and row,
+// returning 0 for a missing part.
+func parseCellRef(ref string) (col, row int, err error) {
+	ref = strings.ToUpper(strings.TrimSpace(ref))
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i < len(ref) {
+		if row, err = strconv.Atoi(ref[i:]); err != nil || row < 1 {
+			return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+	if col == 0 && row == 0 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col, row, nil
+}
+
+// ColumnName returns the letters of the 1-based column n, e.g. 1 is "A" and
+// 27 is "AA".
+func ColumnName(n int) string {
+	var name []byte
+	for n > 0 {
+		n--
+		name = append([]byte{byte('A' + n%26)}, name...)
+		n /= 26
+	}
+	return string(name)
+}
+
+// quoteSheet quotes a sheet name for A1 notation unless it is a plain
+// identifier, as Sheets and Excel do: a name such as "2024", "Retail-EU" or
+// "A1", which reads as a cell, must be quoted.
+func quoteSheet(name string) string {
+	if isPlainSheetName(name) {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// isPlainSheetName reports whether name is made of letters, digits,
+// underscores and dots, as in the file name a CSV source uses, starts with a
+// letter or underscore, and is no cell reference in A1 ("Q1", "FY2024") or
+// R1C1 ("R1C1", "RC") notation.
+func isPlainSheetName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	upper := strings.ToUpper(name)
+	// columns go up to XFD
+	letters := strings.TrimRight(upper, "0123456789")
+	if letters != upper && len(letters) <= 3 && strings.Trim(letters, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		return false
+	}
+	if row, col, ok := strings.Cut(upper, "C"); ok && strings.HasPrefix(row, "R") &&
+		strings.Trim(row[1:], "0123456789") == "" && strings.Trim(col, "0123456789") == "" {
+		return false
+	}
+	return true
+}
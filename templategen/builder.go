@@ -0,0 +1,157 @@
+package templategen
+
+import "fmt"
+
+// builder assembles template configs from the parsed rows.
+//
+// It keeps the tab, grid and chart that are still being filled and adds each
+// one to its parent only when it is closed: a chart by the next chart, grid or
+// tab, a grid by the next grid or tab, and everything by finish. Nothing is
+// added before the sheet starts it, so the output holds exactly the objects
+// in the sheet.
+type builder struct {
+	ids  IDMode
+	path string // ID path of the template, see IDMode
+	// keep holds the IDs to reuse by title path, see previousIDs
+	keep map[string]string
+	// taken holds the kept IDs and those given out, which new IDs avoid
+	taken map[string]bool
+
+	dashboards []TemplateConfigs
+	reports    []TemplateConfigs
+
+	board string
+	tab   *Tab
+	grid  *Grid
+	chart *Chart
+
+	configPath, tabPath, gridPath string
+	configKey, tabKey, gridKey    string
+}
+
+func newBuilder(ids IDMode, templateName string, keep map[string]string) *builder {
+	taken := make(map[string]bool, len(keep))
+	for _, id := range keep {
+		taken[id] = true
+	}
+	return &builder{ids: ids, path: templateName, keep: keep, taken: taken}
+}
+
+// id returns the kept ID of the object at title path key, or else a new ID
+// for the object at path. A kept ID may be the stable ID of another path
+// now, since the objects it was made for moved; a new ID equal to a kept one
+// is made again from path with a suffix.
+func (b *builder) id(key, path string) string {
+	if id, ok := b.keep[key]; ok {
+		return id
+	}
+	id := b.ids.newID(path)
+	for n := 1; b.taken[id]; n++ {
+		id = b.ids.newID(fmt.Sprintf("%s~%d", path, n))
+	}
+	b.taken[id] = true
+	return id
+}
+
+// startTab closes the open tab and starts a new one on board. A board
+// different from the current one starts a new template config as well.
+func (b *builder) startTab(title, board string) {
+	b.closeTab()
+
+	if board != b.board {
+		b.board = board
+		configs := &b.dashboards
+		templateType := TemplateTypeTabGridChart
+		if board == BoardTypeReport {
+			configs = &b.reports
+			templateType = TemplateTypeTabChart
+		}
+		b.configPath = childPath(b.path, board, len(*configs))
+		b.configKey = childPath("", board, len(*configs))
+		*configs = append(*configs, TemplateConfigs{
+			BoardType:        board,
+			TemplateConfigID: b.id(b.configKey, b.configPath),
+			TemplateType:     templateType,
+		})
+	}
+
+	tabs := b.currentConfig().Tabs
+	b.tabPath = childPath(b.configPath, title, len(tabs))
+	b.tabKey = childPath(b.configKey, title, countTitle(tabs, title, func(t Tab) string { return t.Title }))
+	b.tab = &Tab{
+		Title:         title,
+		TemplateTabID: b.id(b.tabKey, b.tabPath),
+	}
+}
+
+// startGrid closes the open grid and starts a new one in the open tab.
+func (b *builder) startGrid(title string) {
+	b.closeGrid()
+	b.gridPath = childPath(b.tabPath, title, len(b.tab.Grids))
+	b.gridKey = childPath(b.tabKey, title, countTitle(b.tab.Grids, title, func(g Grid) string { return g.Title }))
+	b.grid = &Grid{
+		Title:          title,
+		TemplateGridID: b.id(b.gridKey, b.gridPath),
+	}
+}
+
+// startChart closes the open chart and starts a new one in the open grid. A
+// chart placed directly under a tab gets an untitled grid of its own.
+func (b *builder) startChart(chartType, title string) *Chart {
+	b.closeChart()
+	if b.grid == nil {
+		b.startGrid("")
+	}
+	key := childPath(b.gridKey, title, countTitle(b.grid.Charts, title, func(c Chart) string { return c.Title }))
+	b.chart = &Chart{
+		TemplateChartID: b.id(key, childPath(b.gridPath, title, len(b.grid.Charts))),
+		ChartType:       chartType,
+		Title:           title,
+	}
+	return b.chart
+}
+
+func (b *builder) closeChart() {
+	if b.chart == nil {
+		return
+	}
+	b.grid.Charts = append(b.grid.Charts, *b.chart)
+	b.chart = nil
+}
+
+func (b *builder) closeGrid() {
+	b.closeChart()
+	if b.grid == nil {
+		return
+	}
+	b.tab.Grids = append(b.tab.Grids, *b.grid)
+	b.grid = nil
+}
+
+func (b *builder) closeTab() {
+	b.closeGrid()
+	if b.tab == nil {
+		return
+	}
+	config := b.currentConfig()
+	config.Tabs = append(config.Tabs, *b.tab)
+	b.tab = nil
+}
+
+// currentConfig returns the template config of the current board.
+func (b *builder) currentConfig() *TemplateConfigs {
+	if b.board == BoardTypeReport {
+		return &b.reports[len(b.reports)-1]
+	}
+	return &b.dashboards[len(b.dashboards)-1]
+}
+
+// finish closes everything still open and returns the template configs,
+// dashboards first.
+func (b *builder) finish() []TemplateConfigs {
+	b.closeTab()
+	var configs []TemplateConfigs
+	configs = append(configs, b.dashboards...)
+	configs = append(configs, b.reports...)
+	return configs
+}
@@ -0,0 +1,281 @@
+package templategen
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Catalog holds the full definition of known metrics and dimensions, keyed
+// by metric ID.
+type Catalog map[string]Metric
+
+// LoadCatalog reads a catalog from a .json or .csv file, see ReadCatalogJSON
+// and ReadCatalogCSV.
+func LoadCatalog(path string) (Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var catalog Catalog
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		catalog, err = ReadCatalogJSON(f)
+	case ".csv":
+		catalog, err = ReadCatalogCSV(f)
+	default:
+		return nil, fmt.Errorf("%s: unsupported catalog file type", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// ReadCatalogJSON reads a catalog written either as an array of metrics or as
+// an object of metrics keyed by ID, with the field names of the template
+// output ("id", "name", "data_type", ...). The SchemaLegacy names ("dataType",
+// ...) of older catalogs are read too.
+func ReadCatalogJSON(r io.Reader) (Catalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err == nil {
+		metrics := make([]Metric, len(list))
+		for i, raw := range list {
+			if metrics[i], err = unmarshalMetric(raw); err != nil {
+				return nil, fmt.Errorf("metric %d: %w", i+1, err)
+			}
+		}
+		return newCatalog(metrics)
+	}
+	var byID map[string]json.RawMessage
+	if err := json.Unmarshal(data, &byID); err != nil {
+		return nil, fmt.Errorf("catalog must be an array or an object of metrics: %w", err)
+	}
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	catalog := make(Catalog, len(byID))
+	for _, id := range ids {
+		m, err := unmarshalMetric(byID[id])
+		if err != nil {
+			return nil, fmt.Errorf("metric %q: %w", id, err)
+		}
+		if m.ID == "" {
+			m.ID = id
+		}
+		if m.ID != id {
+			return nil, fmt.Errorf("metric %q is listed under ID %q", m.ID, id)
+		}
+		catalog[id] = m
+	}
+	return catalog, nil
+}
+
+func unmarshalMetric(data []byte) (Metric, error) {
+	var m Metric
+	var legacy legacyMetric
+	isLegacy, err := unmarshalEither(data, &m, &legacy)
+	if isLegacy {
+		m = Metric(legacy)
+	}
+	return m, err
+}
+
+// ReadCatalogCSV reads a catalog from CSV with a header row. The headers are
+// the Metric field names, matched like template headers, so "Data Type",
+// "data_type" and "dataType" all work.
+func ReadCatalogCSV(r io.Reader) (Catalog, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("catalog has no header row")
+	}
+
+	setters := map[string]func(m *Metric, v string) error{
+		"id":                func(m *Metric, v string) error { m.ID = v; return nil },
+		"name":              func(m *Metric, v string) error { m.Name = v; return nil },
+		"path":              func(m *Metric, v string) error { m.Path = v; return nil },
+		"type":              func(m *Metric, v string) error { m.Type = v; return nil },
+		"group":             func(m *Metric, v string) error { m.Group = v; return nil },
+		"category":          func(m *Metric, v string) error { m.Category = v; return nil },
+		"datatype":          func(m *Metric, v string) error { m.DataType = v; return nil },
+		"metrictype":        func(m *Metric, v string) error { m.MetricType = v; return nil },
+		"description":       func(m *Metric, v string) error { m.Description = v; return nil },
+		"aggregationmethod": func(m *Metric, v string) error { m.AggregationMethod = v; return nil },
+		"dividebymillion": func(m *Metric, v string) error {
+			if v == "" {
+				return nil
+			}
+			b, ok := parseBool(v)
+			if !ok {
+				return fmt.Errorf("divideByMillion must be TRUE or FALSE, got %q", v)
+			}
+			m.DivideByMillion = b
+			return nil
+		},
+	}
+	header := records[0]
+	hasID := false
+	for _, h := range header {
+		hasID = hasID || headerKey(h) == "id"
+	}
+	if !hasID {
+		return nil, fmt.Errorf("catalog header has no id column")
+	}
+
+	var list []Metric
+	for i, record := range records[1:] {
+		var m Metric
+		for j, v := range record {
+			if j >= len(header) {
+				break
+			}
+			set, ok := setters[headerKey(header[j])]
+			if !ok {
+				continue
+			}
+			if err := set(&m, strings.TrimSpace(v)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+2, err)
+			}
+		}
+		list = append(list, m)
+	}
+	return newCatalog(list)
+}
+
+func newCatalog(list []Metric) (Catalog, error) {
+	catalog := make(Catalog, len(list))
+	for _, m := range list {
+		if m.ID == "" {
+			return nil, fmt.Errorf("metric %q has no ID", m.Name)
+		}
+		if _, dup := catalog[m.ID]; dup {
+			return nil, fmt.Errorf("metric ID %q appears twice", m.ID)
+		}
+		catalog[m.ID] = m
+	}
+	return catalog, nil
+}
+
+// CatalogProblem is a metric of a template that does not match the catalog.
+type CatalogProblem struct {
+	// Location is the title path of the chart holding the metric.
+	Location string
+	// Cell is the ID cell of the metric, when it was read from a sheet.
+	Cell   Location
+	Metric Metric
+	Msg    string
+}
+
+func (p CatalogProblem) String() string {
+	if cell := p.Cell.Cell(); cell != "" {
+		return fmt.Sprintf("%s: %s: %s", cell, p.Location, p.Msg)
+	}
+	return fmt.Sprintf("%s: %s", p.Location, p.Msg)
+}
+
+// Enrich fills in the metrics and dimensions of every chart in cfg from the
+// catalog. Fields already set in the template are kept, so sheet columns
+// override the catalog; as a template cannot tell a Divide By Million of
+// FALSE from none, only TRUE is kept. It returns the metrics whose ID is not
+// in the catalog or whose name differs from the catalog one, and those whose
+// catalog entry has an aggregation method outside DefaultAggregationMethods.
+//
+// Parser.Catalog enriches the metrics as they are read instead, which gives
+// the cell of each problem.
+func (c Catalog) Enrich(cfg *GlobalTemplateConfig) []CatalogProblem {
+	var problems []CatalogProblem
+	enrich := func(location, kind string, metrics []Metric) {
+		for i := range metrics {
+			m := &metrics[i]
+			for _, msg := range c.enrichMetric(m, kind, m.DivideByMillion, nil) {
+				problems = append(problems, CatalogProblem{Location: location, Metric: *m, Msg: msg})
+			}
+		}
+	}
+
+	for ci := range cfg.Global.TemplateConfigs {
+		config := &cfg.Global.TemplateConfigs[ci]
+		for ti := range config.Tabs {
+			tab := &config.Tabs[ti]
+			for gi := range tab.Grids {
+				grid := &tab.Grids[gi]
+				for i := range grid.Charts {
+					chart := &grid.Charts[i]
+					location := strings.Join([]string{tab.Title, grid.Title, chart.Title}, " / ")
+					enrich(location, "dimension", chart.Dimensions)
+					enrich(location, "metric", chart.LeftMetrics)
+					enrich(location, "metric", chart.RightMetrics)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// enrichMetric fills in m, a metric or dimension as kind says, from its
+// catalog entry and returns the problems found. keepDivide keeps the
+// DivideByMillion of m over that of the catalog. The catalog aggregation
+// method must be one of methods, DefaultAggregationMethods when nil.
+func (c Catalog) enrichMetric(m *Metric, kind string, keepDivide bool, methods []string) []string {
+	entry, ok := c[m.ID]
+	if !ok {
+		return []string{fmt.Sprintf("%s ID %q not in catalog", kind, m.ID)}
+	}
+	var problems []string
+	if entry.Name != "" && !strings.EqualFold(m.Name, entry.Name) {
+		problems = append(problems, fmt.Sprintf("%s %q has name %q in catalog", kind, m.ID, entry.Name))
+	}
+	if entry.AggregationMethod != "" {
+		if methods == nil {
+			methods = DefaultAggregationMethods
+		}
+		method, ok := lookupMethod(methods, entry.AggregationMethod)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %q has unknown aggregation method %q in catalog, want one of %s",
+				kind, m.ID, entry.AggregationMethod, strings.Join(methods, ", ")))
+		}
+		entry.AggregationMethod = method
+	}
+	if keepDivide {
+		entry.DivideByMillion = m.DivideByMillion
+	}
+	fillMetric(m, entry)
+	return problems
+}
+
+// fillMetric copies the fields of entry into the empty fields of m, and its
+// DivideByMillion.
+func fillMetric(m *Metric, entry Metric) {
+	fill := func(field *string, v string) {
+		if *field == "" {
+			*field = v
+		}
+	}
+	fill(&m.Name, entry.Name)
+	fill(&m.Path, entry.Path)
+	fill(&m.Type, entry.Type)
+	fill(&m.Group, entry.Group)
+	fill(&m.Category, entry.Category)
+	fill(&m.DataType, entry.DataType)
+	fill(&m.MetricType, entry.MetricType)
+	fill(&m.Description, entry.Description)
+	fill(&m.AggregationMethod, entry.AggregationMethod)
+	m.DivideByMillion = entry.DivideByMillion
+}
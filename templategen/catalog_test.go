@@ -0,0 +1,118 @@
+package templategen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCatalogEnrich(t *testing.T) {
+	catalog, err := ReadCatalogCSV(strings.NewReader(
+		"id,name,path,Data Type,aggregationMethod,divideByMillion\n" +
+			"spend,Spend,cost.spend,currency,SUM,TRUE\n" +
+			"date,Date,time.date,date,,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ReadCatalogJSON(strings.NewReader(`{
+		"spend": {"name": "Spend", "path": "cost.spend", "data_type": "currency", "aggregation_method": "SUM", "divide_by_million": true},
+		"date": {"id": "date", "name": "Date", "path": "time.date", "data_type": "date"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(catalog, fromJSON) {
+		t.Errorf("CSV catalog %+v differs from JSON catalog %+v", catalog, fromJSON)
+	}
+	fromLegacy, err := ReadCatalogJSON(strings.NewReader(`[
+		{"id": "spend", "name": "Spend", "path": "cost.spend", "dataType": "currency", "aggregationMethod": "SUM", "divideByMillion": true},
+		{"id": "date", "name": "Date", "path": "time.date", "dataType": "date"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(catalog, fromLegacy) {
+		t.Errorf("CSV catalog %+v differs from legacy JSON catalog %+v", catalog, fromLegacy)
+	}
+	if _, err := ReadCatalogJSON(strings.NewReader(`[{"id": "spend", "data_tpye": "currency"}]`)); err == nil {
+		t.Error("ReadCatalogJSON with an unknown field: no error")
+	}
+
+	cfg, err := Parse([][]interface{}{
+		row("Overview", "Trends", "Line", "Spend", "Date", "date", "Spend", "spend"),
+		row("", "", "", "", "", "", "Cost", "spend"),
+		row("", "", "", "", "", "", "Clicks", "clicks"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range catalog.Enrich(&cfg) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`Overview / Trends / Spend: metric "spend" has name "Spend" in catalog`,
+		`Overview / Trends / Spend: metric ID "clicks" not in catalog`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+
+	chart := cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0]
+	wantSpend := Metric{ID: "spend", Name: "Spend", Path: "cost.spend", DataType: "currency", AggregationMethod: "SUM", DivideByMillion: true}
+	if chart.LeftMetrics[0] != wantSpend {
+		t.Errorf("left metric = %+v, want %+v", chart.LeftMetrics[0], wantSpend)
+	}
+	if chart.Dimensions[0].Path != "time.date" {
+		t.Errorf("dimension path = %q, want time.date", chart.Dimensions[0].Path)
+	}
+	if chart.RightMetrics[0].Name != "Cost" {
+		t.Errorf("sheet name %q was overwritten by the catalog", chart.RightMetrics[0].Name)
+	}
+}
+
+func TestParserCatalog(t *testing.T) {
+	catalog := Catalog{
+		"spend":  {ID: "spend", Name: "Spend", DataType: "currency", DivideByMillion: true, AggregationMethod: "sum"},
+		"clicks": {ID: "clicks", Name: "Clicks", AggregationMethod: "MEDIAN"},
+		"date":   {ID: "date", Name: "Date", DataType: "date"},
+	}
+	var got []string
+	parser := &Parser{Header: true, Catalog: catalog, OnCatalogProblem: func(p CatalogProblem) {
+		got = append(got, p.String())
+	}}
+	cfg, err := parser.ParseRows(Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Tab", "Grid", "Chart Type", "Chart Title", "Dimension", "Dimension ID", "Metric", "Metric ID", "Divide By Million"),
+			row("Overview", "Trends", "Line", "Spend", "Date", "date", "Spend", "spend", "FALSE"),
+			row("", "", "", "", "", "", "Cost", "spend"),
+			row("", "", "", "", "Day", "day", "Clicks", "clicks"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`Sheet1!H5: Overview / Trends / Spend: metric "spend" has name "Spend" in catalog`,
+		`Sheet1!F6: Overview / Trends / Spend: dimension ID "day" not in catalog`,
+		`Sheet1!H6: Overview / Trends / Spend: metric "clicks" has unknown aggregation method "MEDIAN" in catalog, want one of SUM, AVG, MIN, MAX, COUNT, COUNT_DISTINCT, LAST`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems:\n got %q\nwant %q", got, want)
+	}
+
+	chart := cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0]
+	wantMetrics := []Metric{
+		// the sheet's FALSE wins over the catalog
+		{ID: "spend", Name: "Spend", DataType: "currency", AggregationMethod: "SUM"},
+		{ID: "spend", Name: "Cost", DataType: "currency", AggregationMethod: "SUM", DivideByMillion: true},
+		{ID: "clicks", Name: "Clicks"},
+	}
+	if metrics := append(chart.LeftMetrics, chart.RightMetrics...); !reflect.DeepEqual(metrics, wantMetrics) {
+		t.Errorf("metrics:\n got %+v\nwant %+v", metrics, wantMetrics)
+	}
+}
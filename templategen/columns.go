@@ -0,0 +1,186 @@
+package templategen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Column names a field of the template sheet layout. The value is the
+// canonical header of the column.
+type Column string
+
+const (
+	ColTab           Column = "Tab"
+	ColGrid          Column = "Grid"
+	ColChartType     Column = "Chart Type"
+	ColChartTitle    Column = "Chart Title"
+	ColDimensionName Column = "Dimension"
+	ColDimensionID   Column = "Dimension ID"
+	ColMetricName    Column = "Metric"
+	ColMetricID      Column = "Metric ID"
+	ColBoardType     Column = "Board Type"
+
+	// The metric detail columns fill the Metric fields besides Name and ID,
+	// each the field of the same name: ColType fills Type, ColMetricType
+	// fills MetricType. They are optional and only available through a
+	// header row.
+	ColMetricPath        Column = "Path"
+	ColType              Column = "Type"
+	ColMetricGroup       Column = "Group"
+	ColMetricCategory    Column = "Category"
+	ColDataType          Column = "Data Type"
+	ColMetricType        Column = "Metric Type"
+	ColDescription       Column = "Description"
+	ColDivideByMillion   Column = "Divide By Million"
+	ColAggregationMethod Column = "Aggregation Method"
+
+	// The chart position columns place a chart on its grid by hand, see
+	// GridLayout. They are optional and only available through a header row.
+	ColX      Column = "X"
+	ColY      Column = "Y"
+	ColWidth  Column = "Width"
+	ColHeight Column = "Height"
+
+	// The order columns override the sheet order of the tab, grid or chart
+	// started on the row. They are optional and only available through a
+	// header row.
+	ColTabOrder   Column = "Tab Order"
+	ColGridOrder  Column = "Grid Order"
+	ColChartOrder Column = "Chart Order"
+
+	// ColTheme names the theme of the chart, grid or tab started on the row,
+	// the innermost one. It is optional and only available through a header
+	// row.
+	ColTheme Column = "Theme"
+)
+
+// requiredColumns must be present in a header row.
+var requiredColumns = []Column{ColTab, ColGrid, ColChartType, ColChartTitle, ColMetricName, ColMetricID}
+
+// Layout maps each column to its offset from the first column of the range.
+// Columns missing from a Layout read as empty cells.
+type Layout map[Column]int
+
+// DefaultLayout is the fixed A-I layout of the template sheet.
+var DefaultLayout = Layout{
+	ColTab:           0,
+	ColGrid:          1,
+	ColChartType:     2,
+	ColChartTitle:    3,
+	ColDimensionName: 4,
+	ColDimensionID:   5,
+	ColMetricName:    6,
+	ColMetricID:      7,
+	ColBoardType:     8,
+}
+
+// DefaultAliases are the header names accepted besides the canonical ones.
+// Header matching ignores case, spaces and punctuation, so "chart_type" and
+// "ChartType" already match "Chart Type".
+var DefaultAliases = map[string]Column{
+	"Tab Name":       ColTab,
+	"Tab Title":      ColTab,
+	"Grid Name":      ColGrid,
+	"Grid Title":     ColGrid,
+	"Chart":          ColChartType,
+	"Title":          ColChartTitle,
+	"Chart Name":     ColChartTitle,
+	"Dimension Name": ColDimensionName,
+	"Metric Name":    ColMetricName,
+	"Board":          ColBoardType,
+	"Template Type":  ColBoardType,
+	"Metric Path":    ColMetricPath,
+	"Metric Group":   ColMetricGroup,
+	"Aggregation":    ColAggregationMethod,
+	"In Millions":    ColDivideByMillion,
+	"W":              ColWidth,
+	"H":              ColHeight,
+	"Order":          ColGridOrder,
+	"Position":       ColGridOrder,
+	"Grid Position":  ColGridOrder,
+	"Style":          ColTheme,
+}
+
+// HeaderLayout builds the Layout described by a header row. Headers are
+// matched against the canonical column names, DefaultAliases and aliases,
+// which take precedence; unknown headers are ignored. It fails when a
+// required column is missing or a column appears twice.
+func HeaderLayout(header []interface{}, aliases map[string]Column) (Layout, error) {
+	names := make(map[string]Column)
+	for _, c := range allColumns() {
+		names[headerKey(string(c))] = c
+	}
+	for alias, c := range DefaultAliases {
+		names[headerKey(alias)] = c
+	}
+	for alias, c := range aliases {
+		names[headerKey(alias)] = c
+	}
+
+	layout := make(Layout)
+	for i := range header {
+		c, ok := names[headerKey(cellString(header, i))]
+		if !ok {
+			continue
+		}
+		if _, dup := layout[c]; dup {
+			return nil, fmt.Errorf("column %q appears twice in header", c)
+		}
+		layout[c] = i
+	}
+
+	var missing []string
+	for _, c := range requiredColumns {
+		if _, ok := layout[c]; !ok {
+			missing = append(missing, fmt.Sprintf("%q", c))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required column %s missing from header", strings.Join(missing, ", "))
+	}
+	return layout, nil
+}
+
+// ParseColumn returns the column with the given canonical name or alias.
+func ParseColumn(name string) (Column, error) {
+	key := headerKey(name)
+	for _, c := range allColumns() {
+		if headerKey(string(c)) == key {
+			return c, nil
+		}
+	}
+	for alias, c := range DefaultAliases {
+		if headerKey(alias) == key {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown column %q", name)
+}
+
+// allColumns lists every known column, in DefaultLayout order followed by
+// the header-only ones.
+func allColumns() []Column {
+	return []Column{
+		ColTab, ColGrid, ColChartType, ColChartTitle,
+		ColDimensionName, ColDimensionID, ColMetricName, ColMetricID,
+		ColBoardType,
+		ColMetricPath, ColType, ColMetricGroup, ColMetricCategory,
+		ColDataType, ColMetricType, ColDescription, ColDivideByMillion,
+		ColAggregationMethod,
+		ColX, ColY, ColWidth, ColHeight,
+		ColTabOrder, ColGridOrder, ColChartOrder, ColTheme,
+	}
+}
+
+// headerKey normalises a header for matching: lower case letters and digits
+// only.
+func headerKey(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
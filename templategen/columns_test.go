@@ -0,0 +1,104 @@
+package templategen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeaderLayout(t *testing.T) {
+	header := row("Board", "tab", "Grid Name", "chart_type", "Title", "", "KPI", "KPI Code", "Notes")
+	layout, err := HeaderLayout(header, map[string]Column{"KPI": ColMetricName, "KPI Code": ColMetricID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Layout{
+		ColBoardType:  0,
+		ColTab:        1,
+		ColGrid:       2,
+		ColChartType:  3,
+		ColChartTitle: 4,
+		ColMetricName: 6,
+		ColMetricID:   7,
+	}
+	if !reflect.DeepEqual(layout, want) {
+		t.Errorf("layout = %v, want %v", layout, want)
+	}
+
+	if _, err := HeaderLayout(row("Tab", "Grid", "Chart Type", "Chart Title", "Metric"), nil); err == nil ||
+		err.Error() != `required column "Metric ID" missing from header` {
+		t.Errorf("missing column error = %v", err)
+	}
+	if _, err := HeaderLayout(row("Tab", "Tab Name"), nil); err == nil ||
+		err.Error() != `column "Tab" appears twice in header` {
+		t.Errorf("duplicate column error = %v", err)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Metric ID", "Metric", "Chart Title", "Chart Type", "Grid", "Tab"),
+			row("spend", "Spend", "Spend", "KPI", "KPIs", "Overview"),
+			row("clicks", "", "Clicks", "KPI"),
+		},
+	}
+	cfg, err := (&Parser{Header: true}).ParseRows(rows)
+	if err == nil || err.Error() != "Sheet1!B5: metric ID present but metric name (B) missing" {
+		t.Errorf("ParseRows error = %v", err)
+	}
+	want := []string{
+		"DASHBOARD",
+		"DASHBOARD/Overview",
+		"DASHBOARD/Overview/KPIs",
+		"DASHBOARD/Overview/KPIs/KPI:Spend",
+		"DASHBOARD/Overview/KPIs/KPI:Clicks",
+	}
+	if got := outline(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("outline:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseMetricDetails(t *testing.T) {
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Tab", "Grid", "Chart Type", "Chart Title", "Metric", "Metric ID", "Path", "Data Type", "Divide By Million", "Aggregation Method"),
+			row("Overview", "KPIs", "KPI", "Spend", "Spend", "spend", "cost.spend", "currency", "TRUE", "sum"),
+			row("", "", "KPI", "Clicks", "Clicks", "clicks", "", "", "maybe", "median"),
+			row("", "", "", "", "", "", "cost.cpc"),
+		},
+	}
+	cfg, err := (&Parser{Header: true}).ParseRows(rows)
+
+	list, _ := err.(ErrorList)
+	var got []string
+	for _, e := range list {
+		got = append(got, e.Error())
+	}
+	wantErrs := []string{
+		`Sheet1!I5: divide by million must be TRUE or FALSE, got "maybe"`,
+		`Sheet1!J5: unknown aggregation method "median", want one of SUM, AVG, MIN, MAX, COUNT, COUNT_DISTINCT, LAST`,
+		"Sheet1!E6: metric details given but no metric (E) on this row",
+	}
+	if !reflect.DeepEqual(got, wantErrs) {
+		t.Errorf("errors = %q, want %q", got, wantErrs)
+	}
+
+	spend := cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0].LeftMetrics[0]
+	want := Metric{
+		ID:                "spend",
+		Name:              "Spend",
+		Path:              "cost.spend",
+		DataType:          "currency",
+		DivideByMillion:   true,
+		AggregationMethod: "SUM",
+	}
+	if spend != want {
+		t.Errorf("metric = %+v, want %+v", spend, want)
+	}
+}
@@ -0,0 +1,131 @@
+package templategen
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultHeaderRows is the number of heading rows above the template rows in
+// the template sheet; the Sheets range starts at A4.
+const DefaultHeaderRows = 3
+
+// ReadCSV reads template rows from a CSV export of the template sheet,
+// skipping the first skip lines.
+//
+// The rows have the same shape as the ones returned by the Sheets API:
+// trailing empty cells are dropped, so Parse gives the same result for both.
+// Row i is line skip+1+i of the file: blank lines, and the extra lines of a
+// quoted field spanning several, give empty rows.
+func ReadCSV(r io.Reader, skip int) ([][]interface{}, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var rows [][]interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		// blank lines produce no record, so count lines rather than records
+		line, _ := reader.FieldPos(0)
+		if line <= skip {
+			continue
+		}
+		for len(rows) < line-skip-1 {
+			rows = append(rows, []interface{}{})
+		}
+		rows = append(rows, trimRow(record))
+	}
+	return trimRows(rows), nil
+}
+
+// CSVSource reads the template rows from a CSV file.
+type CSVSource struct {
+	Path string
+	// HeaderRows is the number of lines skipped before the template rows.
+	HeaderRows int
+}
+
+func (s *CSVSource) ReadRows(context.Context) (Rows, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return Rows{}, err
+	}
+	defer f.Close()
+
+	values, err := ReadCSV(f, s.HeaderRows)
+	if err != nil {
+		return Rows{}, fmt.Errorf("%s: %w", s.Path, err)
+	}
+	return Rows{
+		Sheet:    filepath.Base(s.Path),
+		StartRow: s.HeaderRows + 1,
+		StartCol: 1,
+		Values:   values,
+	}, nil
+}
+
+// WriteRows writes rows to the file, placed at their own row and column with
+// empty lines and cells before them.
+func (s *CSVSource) WriteRows(_ context.Context, rows Rows) error {
+	f, err := os.Create(s.Path)
+	if err != nil {
+		return err
+	}
+	if err := WriteCSV(f, rows); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", s.Path, err)
+	}
+	return f.Close()
+}
+
+// WriteCSV writes rows as CSV, placed at their own row and column: ReadCSV
+// skipping rows.StartRow-1 lines reads them back.
+func WriteCSV(w io.Writer, rows Rows) error {
+	writer := csv.NewWriter(w)
+	for i := 1; i < rows.StartRow; i++ {
+		if err := writer.Write(nil); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows.Values {
+		record := make([]string, rows.StartCol-1, rows.StartCol-1+len(row))
+		for j := range row {
+			record = append(record, cellString(row, j))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// trimRow converts a record to a row without its trailing empty cells.
+func trimRow(record []string) []interface{} {
+	end := len(record)
+	for end > 0 && record[end-1] == "" {
+		end--
+	}
+	row := make([]interface{}, end)
+	for i := 0; i < end; i++ {
+		row[i] = record[i]
+	}
+	return row
+}
+
+// trimRows drops trailing empty rows, which the Sheets API leaves out.
+func trimRows(rows [][]interface{}) [][]interface{} {
+	end := len(rows)
+	for end > 0 && len(rows[end-1]) == 0 {
+		end--
+	}
+	return rows[:end]
+}
@@ -0,0 +1,44 @@
+package templategen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReadCSVLines checks that rows keep the line numbers of the file, so
+// that problems are reported at the right cell.
+func TestReadCSVLines(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{
+			name: "blank line",
+			csv: "Heading\n" +
+				"Overview,KPIs,KPI,Spend,,,Spend,spend\n" +
+				"\n" +
+				",,KPI,Clicks,,,Clicks\n",
+			want: "t.csv!G4: metric name present but metric ID (H) missing",
+		},
+		{
+			name: "multi-line field",
+			csv: "Heading\n" +
+				"Overview,KPIs,KPI,\"Spend\nper day\",,,Spend,spend\n" +
+				",,KPI,Clicks,,,Clicks\n",
+			want: "t.csv!G4: metric name present but metric ID (H) missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ReadCSV(strings.NewReader(tt.csv), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = (&Parser{}).ParseRows(Rows{Sheet: "t.csv", StartRow: 2, StartCol: 1, Values: values})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package templategen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ChangeKind says what happened to an object between two templates.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeMoved   ChangeKind = "moved"
+	ChangeRenamed ChangeKind = "renamed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is one difference found by Diff.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	// Object is "board", "tab", "grid", "chart", "metric" or "dimension".
+	Object string `json:"object"`
+	ID     string `json:"id,omitempty"`
+	// Path is the title path of the object in the new template, or in the
+	// old one when it was removed. OldPath is set for moved objects, and Old
+	// and New hold the 1-based positions of those moved among the same
+	// siblings.
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+	// Field names the changed field, with its Old and New value.
+	Field string      `json:"field,omitempty"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s %s", c.Object, c.Path)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s %s", c.Object, c.Path)
+	case ChangeMoved:
+		if c.New != nil {
+			return fmt.Sprintf("> %s %s moved to position %v", c.Object, c.Path, c.New)
+		}
+		if c.OldPath == c.Path {
+			// e.g. a tab moved to the other board of its type
+			return fmt.Sprintf("> %s %s moved to another %s", c.Object, c.Path, parentObject[c.Object])
+		}
+		return fmt.Sprintf("> %s %s moved from %s", c.Object, c.Path, c.OldPath)
+	case ChangeRenamed:
+		return fmt.Sprintf("~ %s %s renamed from %q", c.Object, c.Path, c.Old)
+	}
+	return fmt.Sprintf("~ %s %s: %s %s -> %s", c.Object, c.Path, c.Field, diffValue(c.Old), diffValue(c.New))
+}
+
+var parentObject = map[string]string{"tab": "board", "grid": "tab", "chart": "grid"}
+
+func diffValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case nil:
+		return "none"
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// diffNode is a board, tab, grid or chart in the flattened object tree of a
+// template.
+type diffNode struct {
+	id     string
+	path   string // title path, for matching and messages
+	parent int    // index of the parent node on the level above
+	index  int    // position among its siblings
+	obj    interface{}
+}
+
+// Diff compares two templates structurally. Boards, tabs, grids and charts
+// are matched by ID, and the ones left over by title path, so templates
+// generated with random IDs compare too. It reports the objects added,
+// removed, moved to another parent or position, and renamed, and the
+// changed fields and metrics of the matched ones.
+func Diff(old, new GlobalTemplateConfig) []Change {
+	d := &differ{}
+	oldLevels, newLevels := flatten(old), flatten(new)
+
+	parents := map[int]int{-1: -1}
+	for level, object := range []string{"board", "tab", "grid", "chart"} {
+		parents = d.level(object, oldLevels[level], newLevels[level], parents)
+	}
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+// flatten lists the boards, tabs, grids and charts of cfg level by level.
+func flatten(cfg GlobalTemplateConfig) [4][]diffNode {
+	var levels [4][]diffNode
+	for ci := range cfg.Global.TemplateConfigs {
+		config := &cfg.Global.TemplateConfigs[ci]
+		boards := 0
+		for _, n := range levels[0] {
+			if n.obj.(*TemplateConfigs).BoardType == config.BoardType {
+				boards++
+			}
+		}
+		levels[0] = append(levels[0], diffNode{
+			id:     config.TemplateConfigID,
+			path:   fmt.Sprintf("%s #%d", config.BoardType, boards+1),
+			parent: -1,
+			index:  ci,
+			obj:    config,
+		})
+		for ti := range config.Tabs {
+			tab := &config.Tabs[ti]
+			tabIndex := len(levels[1])
+			levels[1] = append(levels[1], diffNode{
+				id: tab.TemplateTabID, path: tab.Title, parent: len(levels[0]) - 1, index: ti, obj: tab,
+			})
+			for gi := range tab.Grids {
+				grid := &tab.Grids[gi]
+				gridIndex := len(levels[2])
+				gridPath := tab.Title + " / " + displayTitle(grid.Title)
+				levels[2] = append(levels[2], diffNode{
+					id: grid.TemplateGridID, path: gridPath, parent: tabIndex, index: gi, obj: grid,
+				})
+				for i := range grid.Charts {
+					chart := &grid.Charts[i]
+					levels[3] = append(levels[3], diffNode{
+						id: chart.TemplateChartID, path: gridPath + " / " + displayTitle(chart.Title), parent: gridIndex, index: i, obj: chart,
+					})
+				}
+			}
+		}
+	}
+	return levels
+}
+
+func displayTitle(title string) string {
+	if title == "" {
+		return "(untitled)"
+	}
+	return title
+}
+
+// level matches the nodes of one level and reports their changes. parents
+// maps the matched nodes of the level above, old index to new index; the
+// matches of this level are returned the same way.
+func (d *differ) level(object string, old, new []diffNode, parents map[int]int) map[int]int {
+	matches := make(map[int]int)
+	matchedNew := make(map[int]bool)
+
+	byID := make(map[string]int)
+	for j, n := range new {
+		if n.id != "" {
+			byID[n.id] = j
+		}
+	}
+	for i, n := range old {
+		if j, ok := byID[n.id]; ok && n.id != "" && !matchedNew[j] {
+			matches[i] = j
+			matchedNew[j] = true
+		}
+	}
+	byPath := make(map[string][]int)
+	for j, n := range new {
+		if !matchedNew[j] {
+			byPath[n.path] = append(byPath[n.path], j)
+		}
+	}
+	for i, n := range old {
+		if _, ok := matches[i]; ok {
+			continue
+		}
+		if js := byPath[n.path]; len(js) > 0 {
+			matches[i] = js[0]
+			matchedNew[js[0]] = true
+			byPath[n.path] = js[1:]
+		}
+	}
+
+	// the objects of removed or added parents go without saying
+	matchedParents := make(map[int]bool)
+	for _, j := range parents {
+		matchedParents[j] = true
+	}
+	sameParent := func(i, j int) bool {
+		p, ok := parents[old[i].parent]
+		return ok && p == new[j].parent
+	}
+	for i, n := range old {
+		if _, ok := parents[n.parent]; ok && !hasKey(matches, i) {
+			d.add(Change{Kind: ChangeRemoved, Object: object, ID: n.id, Path: n.path})
+		}
+	}
+	for j, n := range new {
+		if matchedParents[n.parent] && !matchedNew[j] {
+			d.add(Change{Kind: ChangeAdded, Object: object, ID: n.id, Path: n.path})
+		}
+	}
+
+	// Siblings that kept their parent are moved when they are not part of
+	// the longest run that kept its order.
+	siblings := make(map[int][]int) // new parent -> old indexes, in old order
+	for i := range old {
+		j, ok := matches[i]
+		if !ok {
+			continue
+		}
+		if !sameParent(i, j) {
+			d.add(Change{Kind: ChangeMoved, Object: object, ID: new[j].id, Path: new[j].path, OldPath: old[i].path})
+			continue
+		}
+		siblings[new[j].parent] = append(siblings[new[j].parent], i)
+	}
+	for i := range old {
+		j, ok := matches[i]
+		if !ok || !sameParent(i, j) {
+			continue
+		}
+		group := siblings[new[j].parent]
+		if group == nil {
+			continue
+		}
+		delete(siblings, new[j].parent)
+		positions := make([]int, len(group))
+		for k, oi := range group {
+			positions[k] = new[matches[oi]].index
+		}
+		kept := longestIncreasing(positions)
+		for k, oi := range group {
+			if !kept[k] {
+				n := new[matches[oi]]
+				d.add(Change{Kind: ChangeMoved, Object: object, ID: n.id, Path: n.path, OldPath: old[oi].path,
+					Old: old[oi].index + 1, New: n.index + 1})
+			}
+		}
+	}
+
+	for i := range old {
+		if j, ok := matches[i]; ok {
+			d.compare(object, old[i], new[j])
+		}
+	}
+	return matches
+}
+
+// compare reports the changed fields of a matched object.
+func (d *differ) compare(object string, old, new diffNode) {
+	field := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			d.add(Change{Kind: ChangeChanged, Object: object, ID: new.id, Path: new.path, Field: name, Old: a, New: b})
+		}
+	}
+	rename := func(a, b string) {
+		if a != b {
+			d.add(Change{Kind: ChangeRenamed, Object: object, ID: new.id, Path: new.path, Old: a, New: b})
+		}
+	}
+
+	switch a := old.obj.(type) {
+	case *TemplateConfigs:
+		b := new.obj.(*TemplateConfigs)
+		field("template_config_name", a.TemplateConfigName, b.TemplateConfigName)
+		field("board_type", a.BoardType, b.BoardType)
+		field("template_type", a.TemplateType, b.TemplateType)
+	case *Tab:
+		b := new.obj.(*Tab)
+		rename(a.Title, b.Title)
+		field("sub_title", a.SubTitle, b.SubTitle)
+	case *Grid:
+		b := new.obj.(*Grid)
+		rename(a.Title, b.Title)
+		field("sub_title", a.SubTitle, b.SubTitle)
+		field("styling", a.Styling, b.Styling)
+	case *Chart:
+		b := new.obj.(*Chart)
+		rename(a.Title, b.Title)
+		field("chart_type", a.ChartType, b.ChartType)
+		field("source", a.Source, b.Source)
+		field("grid_position", a.GridPosition, b.GridPosition)
+		field("styling", a.Styling, b.Styling)
+		d.metrics("dimension", new, axis(a.Dimensions, ""), axis(b.Dimensions, ""))
+		d.metrics("metric", new, append(axis(a.LeftMetrics, "left"), axis(a.RightMetrics, "right")...),
+			append(axis(b.LeftMetrics, "left"), axis(b.RightMetrics, "right")...))
+	}
+}
+
+// axisMetric is a metric of a chart with the axis it is on.
+type axisMetric struct {
+	Metric
+	axis string
+}
+
+func axis(metrics []Metric, name string) []axisMetric {
+	out := make([]axisMetric, len(metrics))
+	for i, m := range metrics {
+		out[i] = axisMetric{m, name}
+	}
+	return out
+}
+
+// metrics reports the metrics or dimensions of a chart added, removed or
+// changed, matched by ID in order.
+func (d *differ) metrics(object string, chart diffNode, old, new []axisMetric) {
+	path := func(m axisMetric) string { return chart.path + " / " + m.ID }
+	matched := make([]bool, len(new))
+	for _, a := range old {
+		j := -1
+		for k, b := range new {
+			if !matched[k] && b.ID == a.ID {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			d.add(Change{Kind: ChangeRemoved, Object: object, ID: a.ID, Path: path(a)})
+			continue
+		}
+		matched[j] = true
+		b := new[j]
+		if a.axis != b.axis {
+			d.add(Change{Kind: ChangeChanged, Object: object, ID: a.ID, Path: path(b), Field: "axis", Old: a.axis, New: b.axis})
+		}
+		va, vb := reflect.ValueOf(a.Metric), reflect.ValueOf(b.Metric)
+		for k := 0; k < va.NumField(); k++ {
+			if fa, fb := va.Field(k).Interface(), vb.Field(k).Interface(); fa != fb {
+				name, _, _ := strings.Cut(va.Type().Field(k).Tag.Get("json"), ",")
+				d.add(Change{Kind: ChangeChanged, Object: object, ID: a.ID, Path: path(b), Field: name, Old: fa, New: fb})
+			}
+		}
+	}
+	for k, b := range new {
+		if !matched[k] {
+			d.add(Change{Kind: ChangeAdded, Object: object, ID: b.ID, Path: path(b)})
+		}
+	}
+}
+
+func hasKey(m map[int]int, k int) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// longestIncreasing marks the elements of the longest strictly increasing
+// subsequence of s.
+func longestIncreasing(s []int) []bool {
+	// length[i] is the length of the longest run ending at i, prev[i] the
+	// element before i in it
+	length := make([]int, len(s))
+	prev := make([]int, len(s))
+	best := -1
+	for i := range s {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if s[j] < s[i] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+	kept := make([]bool, len(s))
+	for i := best; i >= 0; i = prev[i] {
+		kept[i] = true
+	}
+	return kept
+}
@@ -0,0 +1,180 @@
+package templategen
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	rows := Rows{Values: [][]interface{}{
+		row("Overview", "KPIs", "KPI", "Spend", "", "", "Spend", "spend"),
+		row("", "", "KPI", "Clicks", "", "", "Clicks", "clicks"),
+		row("", "", "Bar", "By Channel", "Channel", "channel", "Spend", "spend"),
+		row("", "Trends", "Line", "Daily", "Date", "date", "Spend", "spend"),
+		row("", "Old", "Table", "Raw", "", "", "Spend", "spend"),
+		row("Details", "Campaigns", "Table", "Campaigns", "Campaign", "campaign", "Spend", "spend"),
+	}}
+	old, err := (&Parser{}).ParseRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new := cloneTemplate(t, old)
+
+	overview := &new.Global.TemplateConfigs[0].Tabs[0]
+	overview.Title = "Summary"
+	kpis := &overview.Grids[0]
+	kpis.Charts[0], kpis.Charts[1] = kpis.Charts[1], kpis.Charts[0]
+	trends := &overview.Grids[1]
+	trends.Charts = append(trends.Charts, kpis.Charts[2])
+	kpis.Charts = kpis.Charts[:2]
+	trends.Charts[0].LeftMetrics[0].Name = "Cost"
+	trends.Charts[0].RightMetrics = []Metric{{ID: "clicks", Name: "Clicks"}}
+	overview.Grids = overview.Grids[:2]
+	details := &new.Global.TemplateConfigs[0].Tabs[1]
+	details.Grids[0].Charts = append(details.Grids[0].Charts, Chart{
+		ChartType: "KPI", Title: "Total", TemplateChartID: "new-chart",
+		LeftMetrics: []Metric{{ID: "spend", Name: "Spend"}},
+	})
+
+	var got []string
+	for _, c := range Diff(old, new) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`~ tab Summary renamed from "Overview"`,
+		"- grid Overview / Old",
+		"+ chart Details / Campaigns / Total",
+		"> chart Summary / Trends / By Channel moved from Overview / KPIs / By Channel",
+		"> chart Summary / KPIs / Clicks moved to position 1",
+		`~ metric Summary / Trends / Daily / spend: name "Spend" -> "Cost"`,
+		"+ metric Summary / Trends / Daily / clicks",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes:\n got %q\nwant %q", got, want)
+	}
+	if changes := Diff(old, cloneTemplate(t, old)); len(changes) != 0 {
+		t.Errorf("Diff of equal templates = %v", changes)
+	}
+
+	for _, schema := range []int{SchemaLegacy, SchemaV2} {
+		patch, err := TemplatePatch(old, new, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc, want interface{}
+		data, _ := MarshalTemplate(old, schema)
+		json.Unmarshal(data, &doc)
+		data, _ = MarshalTemplate(new, schema)
+		json.Unmarshal(data, &want)
+		for _, op := range patch {
+			if doc, err = applyPatchOp(doc, op); err != nil {
+				t.Fatalf("schema %d: %v", schema, err)
+			}
+		}
+		if !reflect.DeepEqual(doc, want) {
+			t.Errorf("schema %d: patched template differs from the new one", schema)
+		}
+		moves := 0
+		for _, op := range patch {
+			if op.Op == "move" {
+				moves++
+			}
+		}
+		if moves != 1 {
+			t.Errorf("schema %d: got %d moves, want 1 for the swapped charts", schema, moves)
+		}
+	}
+}
+
+func cloneTemplate(t *testing.T, cfg GlobalTemplateConfig) GlobalTemplateConfig {
+	t.Helper()
+	data, err := MarshalTemplate(cfg, SchemaVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := UnmarshalTemplate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clone
+}
+
+// applyPatchOp applies one JSON Patch operation to doc, enough of RFC 6902
+// for the patches TemplatePatch writes.
+func applyPatchOp(doc interface{}, op PatchOp) (interface{}, error) {
+	var err error
+	switch op.Op {
+	case "add", "replace":
+		return patchAt(doc, op.Path, op.Op, op.Value)
+	case "remove":
+		return patchAt(doc, op.Path, "remove", nil)
+	case "move":
+		var v interface{}
+		if v, err = pointerGet(doc, op.From); err != nil {
+			return nil, err
+		}
+		if doc, err = patchAt(doc, op.From, "remove", nil); err != nil {
+			return nil, err
+		}
+		return patchAt(doc, op.Path, "add", v)
+	}
+	return nil, &SchemaError{Path: op.Path, Msg: "unsupported op " + op.Op}
+}
+
+func pointerGet(doc interface{}, path string) (interface{}, error) {
+	for _, token := range strings.Split(path, "/")[1:] {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			doc = v[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i >= len(v) {
+				return nil, &SchemaError{Path: path, Msg: "no such element"}
+			}
+			doc = v[i]
+		}
+	}
+	return doc, nil
+}
+
+// patchAt performs op at path, returning the changed doc.
+func patchAt(doc interface{}, path, op string, value interface{}) (interface{}, error) {
+	if path == "" {
+		return value, nil
+	}
+	head, rest, nested := strings.Cut(path[1:], "/")
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if nested {
+			child, err := patchAt(v[head], "/"+rest, op, value)
+			v[head] = child
+			return v, err
+		}
+		if op == "remove" {
+			delete(v, head)
+		} else {
+			v[head] = value
+		}
+		return v, nil
+	case []interface{}:
+		i, err := strconv.Atoi(head)
+		if err != nil || i > len(v) {
+			return nil, &SchemaError{Path: path, Msg: "no such element"}
+		}
+		switch {
+		case nested:
+			v[i], err = patchAt(v[i], "/"+rest, op, value)
+			return v, err
+		case op == "remove":
+			return append(v[:i:i], v[i+1:]...), nil
+		case op == "replace":
+			v[i] = value
+			return v, nil
+		}
+		return insertAt(v, i, value), nil
+	}
+	return nil, &SchemaError{Path: path, Msg: "not a container"}
+}
@@ -0,0 +1,74 @@
+package templategen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is a problem found in one cell of the template sheet.
+type ParseError struct {
+	Sheet string
+	// Row and Col are the 1-based sheet coordinates of the cell.
+	Row int
+	Col int
+	Msg string
+}
+
+// Cell returns the A1 reference of the cell, e.g. "Sheet1!G17".
+func (e *ParseError) Cell() string {
+	return Location{Sheet: e.Sheet, Row: e.Row, Col: e.Col}.Cell()
+}
+
+func (e *ParseError) Error() string {
+	return e.Cell() + ": " + e.Msg
+}
+
+// Location is a cell of the template sheet.
+type Location struct {
+	Sheet string
+	// Row and Col are the 1-based sheet coordinates of the cell; zero when
+	// the location is unknown.
+	Row int
+	Col int
+}
+
+// Cell returns the A1 reference of the cell, e.g. "Sheet1!G17", or "" for
+// an unknown location.
+func (l Location) Cell() string {
+	if l.Row == 0 {
+		return ""
+	}
+	cell := ColumnName(l.Col) + fmt.Sprint(l.Row)
+	if l.Sheet == "" {
+		return cell
+	}
+	return quoteSheet(l.Sheet) + "!" + cell
+}
+
+// Locations maps the IDs of tabs, grids and charts to the cell that started
+// them in the sheet.
+type Locations map[string]Location
+
+// ErrorList collects every ParseError of a parse, in sheet order.
+type ErrorList []*ParseError
+
+func (l ErrorList) Error() string {
+	if len(l) == 1 {
+		return l[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors:", len(l))
+	for _, e := range l {
+		b.WriteString("\n  ")
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// Err returns l as an error, or nil when it is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -0,0 +1,109 @@
+package templategen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGolden parses every testdata/*.csv fixture and compares the result with
+// the checked-in testdata/<name>.json, plus testdata/<name>.errors for
+// fixtures that do not parse cleanly and testdata/<name>.issues for those
+// breaking template rules. Run with -update to regenerate them.
+func TestGolden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".csv")
+		t.Run(name, func(t *testing.T) {
+			rows, err := (&CSVSource{Path: fixture, HeaderRows: DefaultHeaderRows}).ReadRows(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, locations, err := (&Parser{TemplateName: name, IDs: IDStable, GridLayout: &GridLayout{}}).ParseRowsWithLocations(rows)
+
+			var gotErrors bytes.Buffer
+			var list ErrorList
+			if errors.As(err, &list) {
+				for _, e := range list {
+					fmt.Fprintln(&gotErrors, e)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			gotJSON, err := MarshalTemplate(cfg, SchemaVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateTemplate(gotJSON, SchemaVersion); err != nil {
+				t.Errorf("output breaks the schema: %v", err)
+			}
+
+			compareGolden(t, filepath.Join("testdata", name+".json"), gotJSON)
+			compareGolden(t, filepath.Join("testdata", name+".errors"), gotErrors.Bytes())
+
+			var gotIssues bytes.Buffer
+			for _, issue := range Validate(cfg, locations) {
+				fmt.Fprintln(&gotIssues, issue)
+			}
+			compareGolden(t, filepath.Join("testdata", name+".issues"), gotIssues.Bytes())
+
+			// fixtures with a legacy golden file check the old shape too
+			legacyPath := filepath.Join("testdata", name+".legacy.json")
+			if _, err := os.Stat(legacyPath); err == nil {
+				legacyJSON, err := MarshalTemplate(cfg, SchemaLegacy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := ValidateTemplate(legacyJSON, SchemaLegacy); err != nil {
+					t.Errorf("legacy output breaks the schema: %v", err)
+				}
+				compareGolden(t, legacyPath, legacyJSON)
+			}
+		})
+	}
+}
+
+// compareGolden checks got against the golden file at path, or rewrites it
+// with -update. An empty result means the golden file should not exist.
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if len(got) == 0 {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) && len(got) == 0 {
+		return
+	}
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match (run go test -update to accept):\n got:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package templategen
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// IDMode selects how the Template*ID fields are generated.
+type IDMode string
+
+const (
+	// IDRandom gives every object a new random UUID on each run.
+	IDRandom IDMode = "random"
+	// IDStable derives a UUIDv5 from the template name and the path of the
+	// object (titles and positions of its tab, grid and chart), so the same
+	// sheet always produces the same IDs.
+	IDStable IDMode = "stable"
+)
+
+// idNamespace is the UUIDv5 namespace of IDStable IDs. Changing it changes
+// every stable ID.
+var idNamespace = uuid.MustParse("3c4f9a52-7d0e-5b8a-9a61-2f6e1c0b7d43")
+
+// ParseIDMode parses the name of an IDMode; "" means IDRandom.
+func ParseIDMode(s string) (IDMode, error) {
+	switch IDMode(s) {
+	case "", IDRandom:
+		return IDRandom, nil
+	case IDStable:
+		return IDStable, nil
+	}
+	return "", fmt.Errorf("unknown ID mode %q (want %q or %q)", s, IDRandom, IDStable)
+}
+
+// newID returns the ID of the object at path.
+func (m IDMode) newID(path string) string {
+	if m == IDStable {
+		return uuid.NewSHA1(idNamespace, []byte(path)).String()
+	}
+	return uuid.New().String()
+}
+
+// childPath appends a titled object at position index to the path of its
+// parent. The position keeps objects with the same title apart.
+func childPath(parent, title string, index int) string {
+	return fmt.Sprintf("%s/%q#%d", parent, title, index)
+}
+
+// countTitle returns the number of items with the given title, which numbers
+// objects of the same title in a title path.
+func countTitle[T any](items []T, title string, titleOf func(T) string) int {
+	n := 0
+	for _, item := range items {
+		if titleOf(item) == title {
+			n++
+		}
+	}
+	return n
+}
+
+// previousIDs returns the IDs of the template configs, tabs, grids and
+// charts of cfg by title path: the board type of the config and the titles of
+// the objects, each numbered among the siblings of the same board type or
+// title. Unlike the ID paths of IDStable, inserting or moving an object
+// leaves the title paths of its siblings alone.
+func previousIDs(cfg GlobalTemplateConfig) map[string]string {
+	ids := make(map[string]string)
+	keep := func(key, id string) {
+		if id != "" {
+			ids[key] = id
+		}
+	}
+	boards := make(map[string]int)
+	for _, config := range cfg.Global.TemplateConfigs {
+		configKey := childPath("", config.BoardType, boards[config.BoardType])
+		boards[config.BoardType]++
+		keep(configKey, config.TemplateConfigID)
+		for ti, tab := range config.Tabs {
+			tabKey := childPath(configKey, tab.Title, countTitle(config.Tabs[:ti], tab.Title, func(t Tab) string { return t.Title }))
+			keep(tabKey, tab.TemplateTabID)
+			for gi, grid := range tab.Grids {
+				gridKey := childPath(tabKey, grid.Title, countTitle(tab.Grids[:gi], grid.Title, func(g Grid) string { return g.Title }))
+				keep(gridKey, grid.TemplateGridID)
+				for ci, chart := range grid.Charts {
+					keep(childPath(gridKey, chart.Title, countTitle(grid.Charts[:ci], chart.Title, func(c Chart) string { return c.Title })), chart.TemplateChartID)
+				}
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,349 @@
+package templategen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema (draft 2020-12) needed to describe the
+// template JSON. TemplateSchema builds it from the Go types, using their json
+// tags for the field names and their schema tags for extra constraints:
+//
+//	BoardType string `json:"board_type" schema:"enum=DASHBOARD|REPORT"`
+//	X         int    `json:"x" schema:"minimum=0"`
+type Schema struct {
+	Dialect              string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 schemaType         `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+	// never makes this the false schema, which matches no value.
+	never bool
+}
+
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// falseSchema is written as false and matches no value; it closes objects
+// to properties they do not list.
+var falseSchema = &Schema{never: true}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte("false"), nil
+	}
+	type plain Schema
+	return json.Marshal((*plain)(s))
+}
+
+// schemaType is the type keyword: one type name, or several.
+type schemaType []string
+
+func (t schemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// TemplateSchema returns the JSON Schema of template JSON in the given
+// schema version.
+func TemplateSchema(schema int) (*Schema, error) {
+	var root reflect.Type
+	switch schema {
+	case SchemaLegacy:
+		root = reflect.TypeOf(legacyConfig{})
+	case SchemaV2:
+		root = reflect.TypeOf(document{})
+	default:
+		return nil, fmt.Errorf("unknown schema version %d", schema)
+	}
+
+	b := &schemaBuilder{defs: make(map[string]*Schema)}
+	s := b.structSchema(root)
+	s.Dialect = schemaDialect
+	s.Title = fmt.Sprintf("Template JSON, schema version %d", schema)
+	s.Defs = b.defs
+	if schema != SchemaLegacy {
+		s.Properties["schema_version"].Const = schema
+	}
+	return s, nil
+}
+
+// schemaBuilder derives schemas from Go types, collecting named struct types
+// in defs.
+type schemaBuilder struct {
+	defs map[string]*Schema
+}
+
+func (b *schemaBuilder) schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: schemaType{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: schemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: schemaType{"integer"}}
+	case reflect.Slice:
+		// nil slices are written as null
+		return &Schema{Type: schemaType{"array", "null"}, Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: schemaType{"object", "null"}, AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		name := strings.TrimPrefix(t.Name(), "legacy")
+		if _, ok := b.defs[name]; !ok {
+			b.defs[name] = nil // reserve the name for recursive types
+			b.defs[name] = b.structSchema(t)
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	}
+	panic(fmt.Sprintf("templategen: no JSON Schema for %s", t))
+}
+
+// structSchema returns the closed object schema of struct type t. Fields
+// without omitempty are required.
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 schemaType{"object"},
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: falseSchema,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(f.Type)
+			for name, p := range embedded.Properties {
+				s.Properties[name] = p
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		p := b.schemaOf(f.Type)
+		constrain(p, f.Tag.Get("schema"))
+		s.Properties[name] = p
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// constrain applies the comma-separated key=value constraints of a schema
+// tag to s.
+func constrain(s *Schema, tag string) {
+	for _, c := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(c, "=")
+		switch key {
+		case "":
+		case "enum":
+			s.Enum = strings.Split(value, "|")
+		case "minimum", "minLength":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				panic(fmt.Sprintf("templategen: bad schema tag %q", tag))
+			}
+			if key == "minimum" {
+				s.Minimum = &n
+			} else {
+				s.MinLength = &n
+			}
+		default:
+			panic(fmt.Sprintf("templategen: unknown schema tag constraint %q", key))
+		}
+	}
+}
+
+// SchemaError is a value of a document that breaks its schema.
+type SchemaError struct {
+	// Path is the JSON Pointer of the value, "" for the document itself.
+	Path string
+	Msg  string
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return e.Path + ": " + e.Msg
+}
+
+// SchemaErrors collects every SchemaError of a document.
+type SchemaErrors []SchemaError
+
+func (l SchemaErrors) Error() string {
+	if len(l) == 1 {
+		return l[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d schema violations:", len(l))
+	for _, e := range l {
+		b.WriteString("\n  ")
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// Err returns l as an error, or nil when it is empty.
+func (l SchemaErrors) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// ValidateTemplate checks template JSON against the schema of the given
+// version and returns its violations as SchemaErrors.
+func ValidateTemplate(data []byte, schema int) error {
+	s, err := TemplateSchema(schema)
+	if err != nil {
+		return err
+	}
+	return s.Validate(data)
+}
+
+// Validate checks the JSON document data against s.
+func (s *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return err
+	}
+	var errs SchemaErrors
+	s.validate(s, v, "", &errs)
+	return errs.Err()
+}
+
+func (s *Schema) validate(root *Schema, v interface{}, path string, errs *SchemaErrors) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+	if s.never {
+		fail("not allowed")
+		return
+	}
+	if s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			fail("unresolved $ref %q", s.Ref)
+			return
+		}
+		def.validate(root, v, path, errs)
+		return
+	}
+
+	kind := jsonType(v)
+	if len(s.Type) > 0 && !typeMatches(s.Type, kind) {
+		fail("got %s, want %s", kind, strings.Join(s.Type, " or "))
+		return
+	}
+	if s.Const != nil && fmt.Sprint(v) != fmt.Sprint(s.Const) {
+		fail("got %v, want %v", v, s.Const)
+	}
+
+	switch v := v.(type) {
+	case string:
+		if len(s.Enum) > 0 && !containsString(s.Enum, v) {
+			fail("got %q, want one of %s", v, strings.Join(s.Enum, ", "))
+		}
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil && s.Minimum != nil && n < int64(*s.Minimum) {
+			fail("got %d, want at least %d", n, *s.Minimum)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(root, item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p, ok := s.Properties[name]
+			if !ok {
+				p = s.AdditionalProperties
+			}
+			if p != nil {
+				p.validate(root, v[name], path+"/"+pointerEscape(name), errs)
+			}
+		}
+	}
+}
+
+// jsonType names the JSON Schema type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeMatches(types schemaType, kind string) bool {
+	for _, t := range types {
+		if t == kind || (t == "number" && kind == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// pointerEscape escapes a property name for a JSON Pointer.
+func pointerEscape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
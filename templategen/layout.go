@@ -0,0 +1,179 @@
+package templategen
+
+import "fmt"
+
+// DefaultGridColumns is the width of the dashboard grid charts are placed on.
+const DefaultGridColumns = 12
+
+// MaxGridUnits is the largest X, Y, width or height the chart position
+// columns take. The layout keeps a row of cells per grid unit, so a far
+// larger value, most likely a typo, would take very long to lay out.
+const MaxGridUnits = 10000
+
+// ChartSize is the default size of a chart type in grid units.
+type ChartSize struct {
+	W, H       int
+	MinW, MinH int
+	MaxH       int
+}
+
+// DefaultChartSizes are the sizes used for chart types a GridLayout does not
+// list itself. Chart types missing here get FallbackChartSize.
+var DefaultChartSizes = map[string]ChartSize{
+	"KPI":   {W: 3, H: 2, MinW: 2, MinH: 2, MaxH: 4},
+	"Line":  {W: 6, H: 4, MinW: 4, MinH: 3, MaxH: 8},
+	"Bar":   {W: 6, H: 4, MinW: 4, MinH: 3, MaxH: 8},
+	"Pie":   {W: 4, H: 4, MinW: 3, MinH: 3, MaxH: 6},
+	"Table": {W: 12, H: 6, MinW: 6, MinH: 4, MaxH: 12},
+}
+
+// FallbackChartSize is the size of chart types without a default size.
+var FallbackChartSize = ChartSize{W: 6, H: 4, MinW: 3, MinH: 3, MaxH: 8}
+
+// GridLayout fills Chart.GridPosition for the charts of each grid.
+//
+// Charts are packed in sheet order onto a grid Columns wide: each one goes to
+// the highest free spot, leftmost first, that fits its size. A chart whose
+// position was set by the author (the X and Y columns) stays where it is, and
+// one whose size was set (Width and Height) keeps that size.
+type GridLayout struct {
+	// Columns is the grid width, DefaultGridColumns when 0.
+	Columns int
+	// Sizes override DefaultChartSizes per chart type.
+	Sizes map[string]ChartSize
+}
+
+// chartHint is what the author set of a chart position in the sheet.
+type chartHint struct {
+	hasPos  bool
+	hasSize bool
+}
+
+// Apply lays out every chart of cfg. Charts that already have a width and
+// height keep that size; positions are always recomputed.
+func (l *GridLayout) Apply(cfg *GlobalTemplateConfig) {
+	l.apply(cfg, nil)
+}
+
+// apply lays out the charts of cfg, keeping what hints (by chart ID) say the
+// author set. It returns the problems found, by chart ID.
+func (l *GridLayout) apply(cfg *GlobalTemplateConfig, hints map[string]chartHint) map[string]string {
+	problems := make(map[string]string)
+	for ci := range cfg.Global.TemplateConfigs {
+		config := &cfg.Global.TemplateConfigs[ci]
+		for ti := range config.Tabs {
+			for gi := range config.Tabs[ti].Grids {
+				l.placeGrid(&config.Tabs[ti].Grids[gi], hints, problems)
+			}
+		}
+	}
+	return problems
+}
+
+func (l *GridLayout) placeGrid(grid *Grid, hints map[string]chartHint, problems map[string]string) {
+	columns := l.columns()
+	occupied := &occupancy{columns: columns}
+
+	// authored positions first, so the packed charts flow around them
+	for i := range grid.Charts {
+		chart := &grid.Charts[i]
+		hint, ok := hints[chart.TemplateChartID]
+		if !ok || !hint.hasPos {
+			continue
+		}
+		l.size(chart, hint.hasSize)
+		pos := &chart.GridPosition
+		if pos.X+pos.W > columns {
+			problems[chart.TemplateChartID] = fmt.Sprintf("chart at X %d with width %d does not fit in %d grid columns", pos.X, pos.W, columns)
+			pos.X = max(0, columns-pos.W)
+		}
+		if !occupied.free(pos.X, pos.Y, pos.W, pos.H) {
+			problems[chart.TemplateChartID] = fmt.Sprintf("chart at X %d, Y %d overlaps another chart", pos.X, pos.Y)
+		}
+		occupied.mark(pos.X, pos.Y, pos.W, pos.H)
+	}
+
+	for i := range grid.Charts {
+		chart := &grid.Charts[i]
+		hint, ok := hints[chart.TemplateChartID]
+		if ok && hint.hasPos {
+			continue
+		}
+		l.size(chart, (ok && hint.hasSize) || (hints == nil && chart.GridPosition.W > 0 && chart.GridPosition.H > 0))
+		pos := &chart.GridPosition
+		pos.X, pos.Y = occupied.find(pos.W, pos.H)
+		occupied.mark(pos.X, pos.Y, pos.W, pos.H)
+	}
+}
+
+// size sets the size limits of chart and, unless keep is set, its size.
+func (l *GridLayout) size(chart *Chart, keep bool) {
+	size, ok := l.Sizes[chart.ChartType]
+	if !ok {
+		if size, ok = DefaultChartSizes[chart.ChartType]; !ok {
+			size = FallbackChartSize
+		}
+	}
+	columns := l.columns()
+
+	pos := &chart.GridPosition
+	if !keep {
+		pos.W, pos.H = size.W, size.H
+	}
+	pos.W = min(max(pos.W, 1), columns)
+	pos.H = max(pos.H, 1)
+	pos.MinW = min(size.MinW, pos.W)
+	pos.MinH = min(size.MinH, pos.H)
+	pos.MaxH = max(size.MaxH, pos.H)
+}
+
+func (l *GridLayout) columns() int {
+	if l.Columns <= 0 {
+		return DefaultGridColumns
+	}
+	return l.Columns
+}
+
+// occupancy tracks the cells of a grid taken by charts. It grows downwards
+// as needed.
+type occupancy struct {
+	columns int
+	rows    [][]bool
+}
+
+func (o *occupancy) taken(x, y int) bool {
+	return y < len(o.rows) && o.rows[y][x]
+}
+
+func (o *occupancy) free(x, y, w, h int) bool {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			if o.taken(x+dx, y+dy) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (o *occupancy) mark(x, y, w, h int) {
+	for len(o.rows) < y+h {
+		o.rows = append(o.rows, make([]bool, o.columns))
+	}
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w && x+dx < o.columns; dx++ {
+			o.rows[y+dy][x+dx] = true
+		}
+	}
+}
+
+// find returns the highest, then leftmost, free spot for a w by h chart.
+func (o *occupancy) find(w, h int) (x, y int) {
+	for y = 0; ; y++ {
+		for x = 0; x+w <= o.columns; x++ {
+			if o.free(x, y, w, h) {
+				return x, y
+			}
+		}
+	}
+}
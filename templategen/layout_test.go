@@ -0,0 +1,84 @@
+package templategen
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// positions lists the X, Y, W and H of the charts in the first grid of cfg.
+func positions(cfg GlobalTemplateConfig) []string {
+	var out []string
+	for _, chart := range cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts {
+		p := chart.GridPosition
+		out = append(out, fmt.Sprintf("%s %d,%d %dx%d", chart.Title, p.X, p.Y, p.W, p.H))
+	}
+	return out
+}
+
+func TestGridLayoutPacking(t *testing.T) {
+	cfg, err := (&Parser{GridLayout: &GridLayout{}}).Parse([][]interface{}{
+		row("Overview", "Main", "KPI", "Spend"),
+		row("", "", "KPI", "Clicks"),
+		row("", "", "Line", "Trend"),
+		row("", "", "Pie", "Share"),
+		row("", "", "KPI", "CTR"),
+		row("", "", "Table", "Detail"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Spend 0,0 3x2",
+		"Clicks 3,0 3x2",
+		"Trend 6,0 6x4",
+		"Share 0,2 4x4",
+		"CTR 4,4 3x2",
+		"Detail 0,6 12x6",
+	}
+	if got := positions(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("positions:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestGridLayoutAuthoredPositions(t *testing.T) {
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Tab", "Grid", "Chart Type", "Chart Title", "Metric", "Metric ID", "X", "Y", "Width", "Height"),
+			row("Overview", "Main", "KPI", "Spend", "", "", "", "", "4", "3"),
+			row("", "", "Line", "Trend", "", "", "0", "0"),
+			row("", "", "KPI", "Clicks", "", "", "10", "0", "4", "2"),
+			row("", "", "KPI", "CTR", "", "", "", "1"),
+			row("", "", "KPI", "Far", "", "", "0", "20000000"),
+		},
+	}
+	cfg, err := (&Parser{Header: true, GridLayout: &GridLayout{}}).ParseRows(rows)
+
+	list, _ := err.(ErrorList)
+	var gotErrs []string
+	for _, e := range list {
+		gotErrs = append(gotErrs, e.Error())
+	}
+	wantErrs := []string{
+		"Sheet1!G6: chart at X 10 with width 4 does not fit in 12 grid columns",
+		"Sheet1!H7: chart position needs both X (G) and Y (H)",
+		"Sheet1!H8: y must be at most 10000, got 20000000",
+	}
+	if !reflect.DeepEqual(gotErrs, wantErrs) {
+		t.Errorf("errors = %q, want %q", gotErrs, wantErrs)
+	}
+
+	want := []string{
+		"Spend 6,2 4x3",
+		"Trend 0,0 6x4",
+		"Clicks 8,0 4x2",
+		"CTR 0,4 3x2",
+		"Far 3,4 3x2",
+	}
+	if got := positions(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("positions:\n got %q\nwant %q", got, want)
+	}
+}
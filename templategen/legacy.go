@@ -0,0 +1,285 @@
+package templategen
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// The legacy types mirror the template types with the field names of
+// SchemaLegacy. Leaf types have the same fields as their current
+// counterparts, so they convert directly. Their schema leaves out the
+// non-empty IDs and chart types and the grid positions from 1 of SchemaV2,
+// which documents generated before it lack.
+
+type legacyConfig struct {
+	Global legacyGlobal `json:"global"`
+}
+
+type legacyGlobal struct {
+	TemplateID      string                  `json:"template_id"`
+	TemplateName    string                  `json:"template_name"`
+	TemplateConfigs []legacyTemplateConfigs `json:"template_configs"`
+}
+
+type legacyTemplateConfigs struct {
+	TemplateConfigName string      `json:"template_config_name"`
+	TemplateType       string      `json:"template_type" schema:"enum=TAB_GRID_CHART|TAB_CHART"`
+	BoardType          string      `json:"board_type" schema:"enum=DASHBOARD|REPORT"`
+	TemplateConfigID   string      `json:"template_config_id"`
+	Tabs               []legacyTab `json:"tabs"`
+}
+
+type legacyTab struct {
+	Title         string       `json:"title"`
+	SubTitle      string       `json:"sub_title"`
+	TemplateTabID string       `json:"template_tab_id"`
+	Grids         []legacyGrid `json:"grids"`
+}
+
+type legacyGrid struct {
+	Title          string            `json:"title"`
+	Position       int               `json:"position"`
+	SubTitle       string            `json:"sub_title"`
+	TemplateGridID string            `json:"template_grid_id"`
+	Styling        legacyGridStyling `json:"styling"`
+	Charts         []legacyChart     `json:"charts"`
+}
+
+type legacyGridStyling struct {
+	TitleStyle    legacyGridFontStyle `json:"titleStyle"`
+	SubTitleStyle legacyGridFontStyle `json:"subTitleStyle"`
+}
+
+type legacyGridFontStyle struct {
+	Font       string   `json:"font"`
+	Color      string   `json:"color"`
+	FontSize   int      `json:"font_size" schema:"minimum=0"`
+	FontFormat []string `json:"font_format"`
+}
+
+type legacyChart struct {
+	ChartType       string             `json:"chart_type"`
+	Source          string             `json:"source"`
+	Title           string             `json:"title"`
+	TemplateChartID string             `json:"template_chart_id"`
+	LeftMetrics     []legacyMetric     `json:"left_metrics"`
+	RightMetrics    []legacyMetric     `json:"right_metrics,omitempty"`
+	Dimensions      []legacyMetric     `json:"dimensions,omitempty"`
+	GridPosition    legacyGridPos      `json:"grid_position"`
+	Styling         legacyChartStyling `json:"styling"`
+}
+
+type legacyChartStyling struct {
+	Palette        int                    `json:"palette" schema:"minimum=0"`
+	TitleStyle     legacyChartFontStyle   `json:"titleStyle"`
+	TableStyle     legacyTableTypeStyle   `json:"tableStyle"`
+	LegendStyle    legacyInsideTableStyle `json:"legendStyle"`
+	LegendPosition string                 `json:"legendPosition"`
+}
+
+type legacyChartFontStyle struct {
+	Font       string   `json:"font"`
+	Color      string   `json:"color"`
+	FontSize   int      `json:"fontSize" schema:"minimum=0"`
+	FontFormat []string `json:"fontFormat"`
+	// written without a tag before SchemaV2, hence the field name
+	Alignment string `json:"Alignment"`
+}
+
+type legacyTableTypeStyle struct {
+	TableHeader  legacyInsideTableStyle `json:"tableHeader"`
+	TableContent legacyInsideTableStyle `json:"tableContent"`
+}
+
+type legacyInsideTableStyle struct {
+	Font     string `json:"font"`
+	FontSize int    `json:"fontSize" schema:"minimum=0"`
+}
+
+type legacyMetric struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	Type              string `json:"type"`
+	Group             string `json:"group"`
+	Category          string `json:"category"`
+	DataType          string `json:"dataType"`
+	MetricType        string `json:"metricType"`
+	Description       string `json:"description"`
+	DivideByMillion   bool   `json:"divideByMillion"`
+	AggregationMethod string `json:"aggregationMethod"`
+}
+
+type legacyGridPos struct {
+	H    int `json:"h" schema:"minimum=0"`
+	W    int `json:"w" schema:"minimum=0"`
+	X    int `json:"x" schema:"minimum=0"`
+	Y    int `json:"y" schema:"minimum=0"`
+	MaxH int `json:"maxH" schema:"minimum=0"`
+	MinH int `json:"minH" schema:"minimum=0"`
+	MinW int `json:"minW" schema:"minimum=0"`
+}
+
+// convertSlice converts each element of s with f, keeping nil slices nil so
+// they are written as null as before.
+func convertSlice[T, U any](s []T, f func(T) U) []U {
+	if s == nil {
+		return nil
+	}
+	out := make([]U, len(s))
+	for i, v := range s {
+		out[i] = f(v)
+	}
+	return out
+}
+
+func toLegacy(cfg GlobalTemplateConfig) legacyConfig {
+	return legacyConfig{Global: legacyGlobal{
+		TemplateID:   cfg.Global.TemplateID,
+		TemplateName: cfg.Global.TemplateName,
+		TemplateConfigs: convertSlice(cfg.Global.TemplateConfigs, func(c TemplateConfigs) legacyTemplateConfigs {
+			return legacyTemplateConfigs{
+				TemplateConfigName: c.TemplateConfigName,
+				TemplateType:       c.TemplateType,
+				BoardType:          c.BoardType,
+				TemplateConfigID:   c.TemplateConfigID,
+				Tabs:               convertSlice(c.Tabs, toLegacyTab),
+			}
+		}),
+	}}
+}
+
+func toLegacyTab(t Tab) legacyTab {
+	return legacyTab{
+		Title:         t.Title,
+		SubTitle:      t.SubTitle,
+		TemplateTabID: t.TemplateTabID,
+		Grids: convertSlice(t.Grids, func(g Grid) legacyGrid {
+			return legacyGrid{
+				Title:          g.Title,
+				Position:       g.Position,
+				SubTitle:       g.SubTitle,
+				TemplateGridID: g.TemplateGridID,
+				Styling: legacyGridStyling{
+					TitleStyle:    legacyGridFontStyle(g.Styling.TitleStyle),
+					SubTitleStyle: legacyGridFontStyle(g.Styling.SubTitleStyle),
+				},
+				Charts: convertSlice(g.Charts, toLegacyChart),
+			}
+		}),
+	}
+}
+
+func toLegacyChart(c Chart) legacyChart {
+	metric := func(m Metric) legacyMetric { return legacyMetric(m) }
+	s := c.Styling
+	return legacyChart{
+		ChartType:       c.ChartType,
+		Source:          c.Source,
+		Title:           c.Title,
+		TemplateChartID: c.TemplateChartID,
+		LeftMetrics:     convertSlice(c.LeftMetrics, metric),
+		RightMetrics:    convertSlice(c.RightMetrics, metric),
+		Dimensions:      convertSlice(c.Dimensions, metric),
+		GridPosition:    legacyGridPos(c.GridPosition),
+		Styling: legacyChartStyling{
+			Palette:    s.Palette,
+			TitleStyle: legacyChartFontStyle(s.TitleStyle),
+			TableStyle: legacyTableTypeStyle{
+				TableHeader:  legacyInsideTableStyle(s.TableStyle.TableHeader),
+				TableContent: legacyInsideTableStyle(s.TableStyle.TableContent),
+			},
+			LegendStyle:    legacyInsideTableStyle(s.LegendStyle),
+			LegendPosition: s.LegendPosition,
+		},
+	}
+}
+
+func fromLegacy(cfg legacyConfig) GlobalTemplateConfig {
+	return GlobalTemplateConfig{Global: Global{
+		TemplateID:   cfg.Global.TemplateID,
+		TemplateName: cfg.Global.TemplateName,
+		TemplateConfigs: convertSlice(cfg.Global.TemplateConfigs, func(c legacyTemplateConfigs) TemplateConfigs {
+			return TemplateConfigs{
+				TemplateConfigName: c.TemplateConfigName,
+				TemplateType:       c.TemplateType,
+				BoardType:          c.BoardType,
+				TemplateConfigID:   c.TemplateConfigID,
+				Tabs:               convertSlice(c.Tabs, fromLegacyTab),
+			}
+		}),
+	}}
+}
+
+func fromLegacyTab(t legacyTab) Tab {
+	return Tab{
+		Title:         t.Title,
+		SubTitle:      t.SubTitle,
+		TemplateTabID: t.TemplateTabID,
+		Grids: convertSlice(t.Grids, func(g legacyGrid) Grid {
+			return Grid{
+				Title:          g.Title,
+				Position:       g.Position,
+				SubTitle:       g.SubTitle,
+				TemplateGridID: g.TemplateGridID,
+				Styling:        fromLegacyGridStyling(g.Styling),
+				Charts:         convertSlice(g.Charts, fromLegacyChart),
+			}
+		}),
+	}
+}
+
+func fromLegacyChart(c legacyChart) Chart {
+	metric := func(m legacyMetric) Metric { return Metric(m) }
+	return Chart{
+		ChartType:       c.ChartType,
+		Source:          c.Source,
+		Title:           c.Title,
+		TemplateChartID: c.TemplateChartID,
+		LeftMetrics:     convertSlice(c.LeftMetrics, metric),
+		RightMetrics:    convertSlice(c.RightMetrics, metric),
+		Dimensions:      convertSlice(c.Dimensions, metric),
+		GridPosition:    GridPos(c.GridPosition),
+		Styling:         fromLegacyChartStyling(c.Styling),
+	}
+}
+
+func fromLegacyGridStyling(s legacyGridStyling) GridStyling {
+	return GridStyling{
+		TitleStyle:    GridFontStyle(s.TitleStyle),
+		SubTitleStyle: GridFontStyle(s.SubTitleStyle),
+	}
+}
+
+func fromLegacyChartStyling(s legacyChartStyling) ChartStyling {
+	return ChartStyling{
+		Palette:    s.Palette,
+		TitleStyle: ChartFontStyle(s.TitleStyle),
+		TableStyle: TableTypeStyle{
+			TableHeader:  InsideTableStyle(s.TableStyle.TableHeader),
+			TableContent: InsideTableStyle(s.TableStyle.TableContent),
+		},
+		LegendStyle:    InsideTableStyle(s.LegendStyle),
+		LegendPosition: s.LegendPosition,
+	}
+}
+
+// unmarshalEither decodes data into v, or failing that into its legacy
+// counterpart, so input files written with the field names of either schema
+// version are read. Unknown fields are an error, so a file mixing the two
+// does not lose the fields of one silently. The error is that of v.
+func unmarshalEither(data []byte, v, legacy interface{}) (isLegacy bool, err error) {
+	if err = unmarshalStrict(data, v); err == nil {
+		return false, nil
+	}
+	if unmarshalStrict(data, legacy) == nil {
+		return true, nil
+	}
+	return false, err
+}
+
+func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,76 @@
+package templategen
+
+import "strings"
+
+// DefaultAggregationMethods are the aggregation methods accepted in the
+// Aggregation Method column when a Parser sets none.
+var DefaultAggregationMethods = []string{"SUM", "AVG", "MIN", "MAX", "COUNT", "COUNT_DISTINCT", "LAST"}
+
+// metricDetails fills the fields of m besides Name and ID from the metric
+// detail columns of the row. Cells that cannot be converted are reported and
+// leave the field unset.
+func (p *Parser) metricDetails(rc rowContext, m *Metric) {
+	m.Path = rc.get(ColMetricPath)
+	m.Type = rc.get(ColType)
+	m.Group = rc.get(ColMetricGroup)
+	m.Category = rc.get(ColMetricCategory)
+	m.DataType = rc.get(ColDataType)
+	m.MetricType = rc.get(ColMetricType)
+	m.Description = rc.get(ColDescription)
+
+	if cell := rc.get(ColDivideByMillion); cell != "" {
+		v, ok := parseBool(cell)
+		if !ok {
+			rc.errorAt(ColDivideByMillion, "divide by million must be TRUE or FALSE, got %q", cell)
+		}
+		m.DivideByMillion = v
+	}
+
+	if cell := rc.get(ColAggregationMethod); cell != "" {
+		methods := p.AggregationMethods
+		if methods == nil {
+			methods = DefaultAggregationMethods
+		}
+		var ok bool
+		if m.AggregationMethod, ok = lookupMethod(methods, cell); !ok {
+			rc.errorAt(ColAggregationMethod, "unknown aggregation method %q, want one of %s", cell, strings.Join(methods, ", "))
+		}
+	}
+}
+
+// lookupMethod returns the aggregation method of methods matching s,
+// ignoring case.
+func lookupMethod(methods []string, s string) (string, bool) {
+	for _, method := range methods {
+		if strings.EqualFold(s, method) {
+			return method, true
+		}
+	}
+	return "", false
+}
+
+// hasMetricDetails reports whether any metric detail column of the row is set.
+func hasMetricDetails(rc rowContext) bool {
+	for _, c := range []Column{
+		ColMetricPath, ColType, ColMetricGroup, ColMetricCategory, ColDataType,
+		ColMetricType, ColDescription, ColDivideByMillion, ColAggregationMethod,
+	} {
+		if rc.get(c) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBool reads the ways a spreadsheet author writes a boolean: TRUE/FALSE
+// (as Sheets and Excel export checkboxes), yes/no, y/n, 1/0 and x for a
+// ticked cell.
+func parseBool(s string) (v, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "y", "1", "x":
+		return true, true
+	case "false", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
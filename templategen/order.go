@@ -0,0 +1,93 @@
+package templategen
+
+import "sort"
+
+// explicitOrder is a position set by the author in one of the order columns,
+// with the cell it came from.
+type explicitOrder struct {
+	pos int
+	rc  rowContext
+	col Column
+}
+
+// applyOrder sorts the tabs of each template config, the grids of each tab
+// and the charts of each grid by position, and sets Grid.Position.
+//
+// An object's position is its order from the sheet (keyed by object ID in
+// orders) or else its 1-based place in the sheet. Objects with the same
+// position are reported and keep their sheet order.
+func applyOrder(cfg *GlobalTemplateConfig, orders map[string]explicitOrder) {
+	for ci := range cfg.Global.TemplateConfigs {
+		config := &cfg.Global.TemplateConfigs[ci]
+
+		pos := orderPositions(len(config.Tabs), "tab", orders,
+			func(i int) string { return config.Tabs[i].TemplateTabID },
+			func(i int) string { return config.Tabs[i].Title })
+		config.Tabs = reorder(config.Tabs, pos)
+
+		for ti := range config.Tabs {
+			tab := &config.Tabs[ti]
+			pos := orderPositions(len(tab.Grids), "grid", orders,
+				func(i int) string { return tab.Grids[i].TemplateGridID },
+				func(i int) string { return tab.Grids[i].Title })
+			for i := range tab.Grids {
+				tab.Grids[i].Position = pos[i]
+			}
+			tab.Grids = reorder(tab.Grids, pos)
+
+			for gi := range tab.Grids {
+				grid := &tab.Grids[gi]
+				pos := orderPositions(len(grid.Charts), "chart", orders,
+					func(i int) string { return grid.Charts[i].TemplateChartID },
+					func(i int) string { return grid.Charts[i].Title })
+				grid.Charts = reorder(grid.Charts, pos)
+			}
+		}
+	}
+}
+
+// orderPositions returns the position of each of n sibling objects and reports
+// duplicates at the order cell that caused them.
+func orderPositions(n int, kind string, orders map[string]explicitOrder, id, title func(int) string) []int {
+	pos := make([]int, n)
+	seen := make(map[int]int)
+	for i := range pos {
+		order, explicit := orders[id(i)]
+		pos[i] = i + 1
+		if explicit {
+			pos[i] = order.pos
+		}
+
+		first, dup := seen[pos[i]]
+		if !dup {
+			seen[pos[i]] = i
+			continue
+		}
+		if !explicit {
+			// the earlier object took this place through its order column
+			order = orders[id(first)]
+			first = i
+		}
+		order.rc.errorAt(order.col, "%s position %d is already taken by %s %q", kind, pos[i], kind, title(first))
+	}
+	return pos
+}
+
+// reorder returns items sorted by pos, keeping the current order for equal
+// positions.
+func reorder[T any](items []T, pos []int) []T {
+	if len(items) == 0 {
+		return items
+	}
+	index := make([]int, len(items))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool { return pos[index[a]] < pos[index[b]] })
+
+	sorted := make([]T, 0, len(items))
+	for _, i := range index {
+		sorted = append(sorted, items[i])
+	}
+	return sorted
+}
@@ -0,0 +1,63 @@
+package templategen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOrder(t *testing.T) {
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Tab", "Tab Order", "Grid", "Order", "Chart Type", "Chart Title", "Chart Order", "Metric", "Metric ID"),
+			row("Details", "2", "Spend", "", "KPI", "Spend", "", "Spend", "spend"),
+			row("Overview", "1", "Trends", "2", "Line", "Spend", "2", "Spend", "spend"),
+			row("", "", "", "", "Line", "Clicks", "1", "Clicks", "clicks"),
+			row("", "", "KPIs", "1", "KPI", "CTR", "", "CTR", "ctr"),
+			row("", "", "More", "1", "KPI", "CPC", "", "CPC", "cpc"),
+			row("", "3", "", "", "", "", "", "", ""),
+		},
+	}
+	cfg, err := (&Parser{Header: true}).ParseRows(rows)
+
+	list, _ := err.(ErrorList)
+	var got []string
+	for _, e := range list {
+		got = append(got, e.Error())
+	}
+	wantErrs := []string{
+		`Sheet1!D8: grid position 1 is already taken by grid "KPIs"`,
+		"Sheet1!B9: tab order given on a row that starts no tab",
+	}
+	if !reflect.DeepEqual(got, wantErrs) {
+		t.Errorf("errors = %q, want %q", got, wantErrs)
+	}
+
+	want := []string{
+		"DASHBOARD",
+		"DASHBOARD/Overview",
+		"DASHBOARD/Overview/KPIs",
+		"DASHBOARD/Overview/KPIs/KPI:CTR",
+		"DASHBOARD/Overview/More",
+		"DASHBOARD/Overview/More/KPI:CPC",
+		"DASHBOARD/Overview/Trends",
+		"DASHBOARD/Overview/Trends/Line:Clicks",
+		"DASHBOARD/Overview/Trends/Line:Spend",
+		"DASHBOARD/Details",
+		"DASHBOARD/Details/Spend",
+		"DASHBOARD/Details/Spend/KPI:Spend",
+	}
+	if got := outline(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("outline:\n got %q\nwant %q", got, want)
+	}
+
+	var positions []int
+	for _, grid := range cfg.Global.TemplateConfigs[0].Tabs[0].Grids {
+		positions = append(positions, grid.Position)
+	}
+	if want := []int{1, 1, 2}; !reflect.DeepEqual(positions, want) {
+		t.Errorf("grid positions = %v, want %v", positions, want)
+	}
+}
@@ -0,0 +1,470 @@
+// Package templategen turns the rows of a template layout sheet into a
+// GlobalTemplateConfig.
+//
+// Each row follows the A-J layout of the template sheet: column A starts a
+// new tab, B a new grid, C holds the chart type and D the chart title, E/F the
+// dimension name and ID and G/H the metric name and ID. Column I of a tab row
+// sets the board type of the tab, "Dashboard" or "Report". Rows with an empty
+// chart type continue the chart above them. Tabs, grids and charts keep the
+// order of the sheet unless a header row adds order columns. Rows may be
+// shorter than the layout, as the Sheets API returns them; missing cells
+// count as empty.
+package templategen
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultTemplateName is used when a Parser has no TemplateName set.
+const DefaultTemplateName = "Generated Template"
+
+const (
+	BoardTypeDashboard = "DASHBOARD"
+	BoardTypeReport    = "REPORT"
+
+	TemplateTypeTabGridChart = "TAB_GRID_CHART"
+	TemplateTypeTabChart     = "TAB_CHART"
+)
+
+// Parser converts sheet rows into a GlobalTemplateConfig.
+type Parser struct {
+	// TemplateName is written to Global.TemplateName.
+	TemplateName string
+	// IDs selects how IDs are generated, IDRandom when empty.
+	IDs IDMode
+	// StrictBoardType turns off the legacy rule that makes a tab without a
+	// board type a report when its title contains "Report"; such tabs are
+	// dashboards instead.
+	StrictBoardType bool
+	// Header makes the first non-empty row a header row naming the columns,
+	// see HeaderLayout. Otherwise Layout is used. The metric detail columns,
+	// such as Path or Aggregation Method, need a header row.
+	Header bool
+	// Aliases are extra header names for Header mode.
+	Aliases map[string]Column
+	// Layout gives the column positions when Header is false, DefaultLayout
+	// when nil.
+	Layout Layout
+	// AggregationMethods are the values allowed in the Aggregation Method
+	// column, DefaultAggregationMethods when nil.
+	AggregationMethods []string
+	// GridLayout, when set, fills the GridPosition of every chart.
+	GridLayout *GridLayout
+	// Themes are the styling presets available to the template. Grids and
+	// charts get the styling of Theme, or of DefaultThemeName when Theme is
+	// empty, unless the Theme column picks another one.
+	Themes Themes
+	// Theme is the name of the template theme.
+	Theme string
+	// Catalog, when set, fills in every metric and dimension from its
+	// catalog entry as the rows are read, as Catalog.Enrich does. Cells of
+	// the sheet win over the catalog, a Divide By Million of FALSE included.
+	Catalog Catalog
+	// OnCatalogProblem, when set, is called with each metric not matching
+	// the catalog, in sheet order.
+	OnCatalogProblem func(CatalogProblem)
+	// Previous, when set, is the template generated from an earlier version
+	// of the sheet. The template and the objects found at the same title path
+	// in it keep their IDs, so that links to them survive; only new objects
+	// get IDs as set by IDs.
+	Previous *GlobalTemplateConfig
+}
+
+// Parse converts rows using a Parser with default settings.
+//
+// rows has the shape returned by Spreadsheets.Values.Get, i.e. the first
+// element is the row read from A4 of the template sheet.
+func Parse(rows [][]interface{}) (GlobalTemplateConfig, error) {
+	return (&Parser{}).Parse(rows)
+}
+
+// Parse converts rows read from DefaultRange into a GlobalTemplateConfig.
+func (p *Parser) Parse(rows [][]interface{}) (GlobalTemplateConfig, error) {
+	rng, _ := ParseRange(DefaultRange)
+	return p.ParseRows(Rows{
+		Sheet:    rng.Sheet,
+		StartRow: rng.StartRow,
+		StartCol: rng.StartCol,
+		Values:   rows,
+	})
+}
+
+// ParseRows converts rows into a GlobalTemplateConfig.
+//
+// Problems in the rows are returned together as an ErrorList naming the cell
+// of each one. The rows that have none are still converted, so the returned
+// config is usable for inspection even when the error is non-nil.
+func (p *Parser) ParseRows(rows Rows) (GlobalTemplateConfig, error) {
+	cfg, _, err := p.ParseRowsWithLocations(rows)
+	return cfg, err
+}
+
+// ParseRowsWithLocations is ParseRows, also returning the cell each tab,
+// grid and chart was started from, for reporting problems found later on.
+func (p *Parser) ParseRowsWithLocations(rows Rows) (GlobalTemplateConfig, Locations, error) {
+	name := p.TemplateName
+	if name == "" {
+		name = DefaultTemplateName
+	}
+	theme := p.Theme
+	if theme == "" {
+		theme = DefaultThemeName
+	} else if _, ok := p.Themes.lookup(theme); !ok {
+		return GlobalTemplateConfig{}, nil, fmt.Errorf("unknown theme %q", theme)
+	}
+	finalTemplateConfig := GlobalTemplateConfig{
+		Global: Global{
+			TemplateID:   p.IDs.newID(name),
+			TemplateName: name,
+		},
+	}
+	var keep map[string]string
+	if p.Previous != nil {
+		keep = previousIDs(*p.Previous)
+		if id := p.Previous.Global.TemplateID; id != "" {
+			finalTemplateConfig.Global.TemplateID = id
+		}
+	}
+
+	var errs ErrorList
+	b := newBuilder(p.IDs, name, keep)
+	locations := make(Locations)
+	// charts by ID: the position set by the author, and the row starting them
+	hints := make(map[string]chartHint)
+	chartRows := make(map[string]rowContext)
+	// tabs, grids and charts by ID: the order set by the author
+	orders := make(map[string]explicitOrder)
+	readOrder := func(rc rowContext, c Column, id, kind string) {
+		if rc.get(c) == "" {
+			return
+		}
+		if id == "" {
+			rc.errorAt(c, "%s order given on a row that starts no %s", kind, kind)
+			return
+		}
+		if n, ok := rc.number(c, 1, math.MaxInt); ok {
+			orders[id] = explicitOrder{pos: n, rc: rc, col: c}
+		}
+	}
+	// tabs, grids and charts by ID: the theme picked by the author
+	themes := make(map[string]string)
+	readTheme := func(rc rowContext, id string) {
+		cell := rc.get(ColTheme)
+		switch _, ok := p.Themes.lookup(cell); {
+		case cell == "":
+		case id == "":
+			rc.errorAt(ColTheme, "theme given on a row that starts no tab, grid or chart")
+		case !ok:
+			rc.errorAt(ColTheme, "unknown theme %q, want one of %s", cell, p.Themes.names())
+		default:
+			themes[id] = cell
+		}
+	}
+
+	// enrich fills in a metric or dimension of chart read from the row
+	enrich := func(rc rowContext, chart *Chart, m *Metric, idCol Column, kind string) {
+		if p.Catalog == nil {
+			return
+		}
+		keepDivide := kind == "metric" && rc.get(ColDivideByMillion) != ""
+		for _, msg := range p.Catalog.enrichMetric(m, kind, keepDivide, p.AggregationMethods) {
+			if p.OnCatalogProblem != nil {
+				p.OnCatalogProblem(CatalogProblem{
+					Location: strings.Join([]string{b.tab.Title, b.grid.Title, chart.Title}, " / "),
+					Cell:     rc.location(idCol),
+					Metric:   *m,
+					Msg:      msg,
+				})
+			}
+		}
+	}
+
+	layout := p.Layout
+	if layout == nil {
+		layout = DefaultLayout
+	}
+	values := rows.Values
+	if p.Header {
+		i := 0
+		for i < len(values) && len(values[i]) == 0 {
+			i++
+		}
+		if i == len(values) {
+			return finalTemplateConfig, nil, ErrorList{{Sheet: rows.Sheet, Row: rows.StartRow, Col: rows.StartCol, Msg: "header row missing"}}
+		}
+		var err error
+		if layout, err = HeaderLayout(values[i], p.Aliases); err != nil {
+			return finalTemplateConfig, nil, ErrorList{{Sheet: rows.Sheet, Row: rows.StartRow + i, Col: rows.StartCol, Msg: err.Error()}}
+		}
+		// keep the row numbers of the remaining rows
+		rows.StartRow += i + 1
+		values = values[i+1:]
+	}
+
+	for i, row := range values {
+		if len(row) == 0 {
+			continue
+		}
+		rc := rowContext{rows: &rows, index: i, errs: &errs, layout: layout, row: row}
+
+		// missing trailing cells (the Sheets API trims them) and columns
+		// missing from the layout read as empty, so short rows need no
+		// special casing
+
+		startedTab, startedGrid := "", ""
+		if cell := rc.get(ColTab); cell != "" {
+			b.startTab(cell, p.boardType(rc))
+			startedTab = b.tab.TemplateTabID
+			locations[startedTab] = rc.location(ColTab)
+		} else if b.tab == nil {
+			// nothing to attach the row to until the first tab
+			for col := range row {
+				if cellString(row, col) != "" {
+					rc.errorf(col, "row has content but no tab (%s) has been started yet", rc.column(ColTab))
+					break
+				}
+			}
+			continue
+		} else if rc.get(ColBoardType) != "" {
+			rc.errorAt(ColBoardType, "board type given on a row that starts no tab (%s)", rc.column(ColTab))
+		}
+
+		if cell := rc.get(ColGrid); cell != "" {
+			b.startGrid(cell)
+			startedGrid = b.grid.TemplateGridID
+			locations[startedGrid] = rc.location(ColGrid)
+		}
+		readOrder(rc, ColTabOrder, startedTab, "tab")
+		readOrder(rc, ColGridOrder, startedGrid, "grid")
+
+		dimension, dimensionOK := rc.pair(ColDimensionName, ColDimensionID, "dimension")
+		metric, metricOK := rc.pair(ColMetricName, ColMetricID, "metric")
+		if metricOK {
+			p.metricDetails(rc, &metric)
+		} else if hasMetricDetails(rc) {
+			rc.errorAt(ColMetricName, "metric details given but no metric (%s) on this row", rc.column(ColMetricName))
+		}
+
+		if chartType := rc.get(ColChartType); chartType != "" {
+			chart := b.startChart(chartType, rc.get(ColChartTitle))
+			locations[chart.TemplateChartID] = rc.location(ColChartType)
+			if _, ok := locations[b.grid.TemplateGridID]; !ok {
+				// the untitled grid of a chart placed directly under a tab
+				locations[b.grid.TemplateGridID] = rc.location(ColChartType)
+			}
+			if hint := chartPosition(rc, chart); hint != (chartHint{}) {
+				hints[chart.TemplateChartID] = hint
+			}
+			chartRows[chart.TemplateChartID] = rc
+			if dimensionOK {
+				enrich(rc, chart, &dimension, ColDimensionID, "dimension")
+				chart.Dimensions = append(chart.Dimensions, dimension)
+			}
+			// the first metric of a chart always goes to the left axis
+			if metricOK {
+				enrich(rc, chart, &metric, ColMetricID, "metric")
+				chart.LeftMetrics = append(chart.LeftMetrics, metric)
+			}
+			readOrder(rc, ColChartOrder, chart.TemplateChartID, "chart")
+			readTheme(rc, chart.TemplateChartID)
+			continue
+		}
+		readOrder(rc, ColChartOrder, "", "chart")
+		readTheme(rc, pick(startedGrid != "", startedGrid, startedTab))
+
+		// continuation row of the open chart
+		if rc.get(ColChartTitle) != "" {
+			rc.errorAt(ColChartTitle, "chart title present but chart type (%s) missing", rc.column(ColChartType))
+		}
+		if b.chart == nil {
+			if dimensionOK || metricOK {
+				rc.errorAt(ColChartType, "dimension or metric given but no chart is open in this grid")
+			}
+			continue
+		}
+		if dimensionOK {
+			enrich(rc, b.chart, &dimension, ColDimensionID, "dimension")
+			b.chart.Dimensions = append(b.chart.Dimensions, dimension)
+		}
+		if metricOK {
+			enrich(rc, b.chart, &metric, ColMetricID, "metric")
+			if b.chart.ChartType == "Line" {
+				b.chart.RightMetrics = append(b.chart.RightMetrics, metric)
+			} else {
+				b.chart.LeftMetrics = append(b.chart.LeftMetrics, metric)
+			}
+		}
+	}
+	finalTemplateConfig.Global.TemplateConfigs = b.finish()
+	applyOrder(&finalTemplateConfig, orders)
+	applyThemes(&finalTemplateConfig, p.Themes, theme, themes)
+
+	if p.GridLayout != nil {
+		for id, problem := range p.GridLayout.apply(&finalTemplateConfig, hints) {
+			rc := chartRows[id]
+			rc.errorAt(ColX, "%s", problem)
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Row != errs[j].Row {
+			return errs[i].Row < errs[j].Row
+		}
+		return errs[i].Col < errs[j].Col
+	})
+	return finalTemplateConfig, locations, errs.Err()
+}
+
+// boardType returns the board type of the tab started by the row: the one in
+// the board type column, or else the legacy title rule.
+func (p *Parser) boardType(rc rowContext) string {
+	switch cell := rc.get(ColBoardType); strings.ToUpper(strings.TrimSpace(cell)) {
+	case BoardTypeDashboard:
+		return BoardTypeDashboard
+	case BoardTypeReport:
+		return BoardTypeReport
+	case "":
+		if !p.StrictBoardType && strings.Contains(rc.get(ColTab), "Report") {
+			return BoardTypeReport
+		}
+		return BoardTypeDashboard
+	default:
+		rc.errorAt(ColBoardType, "unknown board type %q, want Dashboard or Report", cell)
+		return BoardTypeDashboard
+	}
+}
+
+// chartPosition reads the position columns of the row starting chart into
+// its GridPosition and reports which of them the author set.
+func chartPosition(rc rowContext, chart *Chart) chartHint {
+	var hint chartHint
+	x, hasX := rc.number(ColX, 0, MaxGridUnits)
+	y, hasY := rc.number(ColY, 0, MaxGridUnits)
+	w, hasW := rc.number(ColWidth, 1, MaxGridUnits)
+	h, hasH := rc.number(ColHeight, 1, MaxGridUnits)
+	// an invalid cell is reported by number already
+	setX, setY := rc.get(ColX) != "", rc.get(ColY) != ""
+	setW, setH := rc.get(ColWidth) != "", rc.get(ColHeight) != ""
+	if setX != setY {
+		rc.errorAt(pick(setX, ColX, ColY), "chart position needs both X (%s) and Y (%s)", rc.column(ColX), rc.column(ColY))
+	} else if hasX && hasY {
+		chart.GridPosition.X, chart.GridPosition.Y = x, y
+		hint.hasPos = true
+	}
+	if setW != setH {
+		rc.errorAt(pick(setW, ColWidth, ColHeight), "chart size needs both width (%s) and height (%s)", rc.column(ColWidth), rc.column(ColHeight))
+	} else if hasW && hasH {
+		chart.GridPosition.W, chart.GridPosition.H = w, h
+		hint.hasSize = true
+	}
+	return hint
+}
+
+// pick returns a if cond is set and b otherwise.
+func pick[T any](cond bool, a, b T) T {
+	if cond {
+		return a
+	}
+	return b
+}
+
+// rowContext reads the cells of one row of rows and reports its problems.
+type rowContext struct {
+	rows   *Rows
+	index  int
+	errs   *ErrorList
+	layout Layout
+	row    []interface{}
+}
+
+// get returns the cell of column c, or "" when the layout has no such column.
+func (rc rowContext) get(c Column) string {
+	col, ok := rc.layout[c]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(cellString(rc.row, col))
+}
+
+// number reads column c as a whole number from min to max. ok is false when
+// the cell is empty or invalid, which is reported.
+func (rc rowContext) number(c Column, min, max int) (n int, ok bool) {
+	cell := rc.get(c)
+	if cell == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(cell)
+	switch {
+	case err != nil || n < min:
+		rc.errorAt(c, "%s must be a whole number of at least %d, got %q", strings.ToLower(string(c)), min, cell)
+		return 0, false
+	case n > max:
+		rc.errorAt(c, "%s must be at most %d, got %d", strings.ToLower(string(c)), max, n)
+		return 0, false
+	}
+	return n, true
+}
+
+// errorf records a ParseError for column offset col of the row.
+func (rc rowContext) errorf(col int, format string, args ...interface{}) {
+	*rc.errs = append(*rc.errs, &ParseError{
+		Sheet: rc.rows.Sheet,
+		Row:   rc.rows.StartRow + rc.index,
+		Col:   rc.rows.StartCol + col,
+		Msg:   fmt.Sprintf(format, args...),
+	})
+}
+
+// location returns the cell of column c of the row.
+func (rc rowContext) location(c Column) Location {
+	return Location{
+		Sheet: rc.rows.Sheet,
+		Row:   rc.rows.StartRow + rc.index,
+		Col:   rc.rows.StartCol + rc.layout[c],
+	}
+}
+
+// errorAt records a ParseError for column c of the row.
+func (rc rowContext) errorAt(c Column, format string, args ...interface{}) {
+	rc.errorf(rc.layout[c], format, args...)
+}
+
+// column returns the sheet column letters of column c.
+func (rc rowContext) column(c Column) string {
+	col, ok := rc.layout[c]
+	if !ok {
+		return fmt.Sprintf("%q", c)
+	}
+	return ColumnName(rc.rows.StartCol + col)
+}
+
+// pair reads the name and ID columns of a dimension or metric. ok is false
+// when both are empty, or when only one of them is set, which is reported at
+// the name cell.
+func (rc rowContext) pair(nameCol, idCol Column, kind string) (m Metric, ok bool) {
+	name, id := rc.get(nameCol), rc.get(idCol)
+	switch {
+	case name == "" && id == "":
+		return Metric{}, false
+	case id == "":
+		rc.errorAt(nameCol, "%s name present but %s ID (%s) missing", kind, kind, rc.column(idCol))
+		return Metric{}, false
+	case name == "":
+		rc.errorAt(nameCol, "%s ID present but %s name (%s) missing", kind, kind, rc.column(nameCol))
+		return Metric{}, false
+	}
+	return Metric{Name: name, ID: id}, true
+}
+
+// cellString returns the value of column i as a string, or "" when the row
+// is shorter than that.
+func cellString(row []interface{}, i int) string {
+	if i >= len(row) || row[i] == nil {
+		return ""
+	}
+	return fmt.Sprint(row[i])
+}
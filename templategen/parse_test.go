@@ -0,0 +1,371 @@
+package templategen
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func row(cells ...interface{}) []interface{} { return cells }
+
+// outline lists every config, tab, grid and chart of cfg as a slash-separated
+// title path, so tests can compare structure without the random IDs.
+func outline(cfg GlobalTemplateConfig) []string {
+	var out []string
+	for _, config := range cfg.Global.TemplateConfigs {
+		out = append(out, config.BoardType)
+		for _, tab := range config.Tabs {
+			out = append(out, config.BoardType+"/"+tab.Title)
+			for _, grid := range tab.Grids {
+				out = append(out, config.BoardType+"/"+tab.Title+"/"+grid.Title)
+				for _, chart := range grid.Charts {
+					out = append(out, config.BoardType+"/"+tab.Title+"/"+grid.Title+"/"+chart.ChartType+":"+chart.Title)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestParseStructure(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]interface{}
+		want []string
+	}{
+		{
+			name: "no placeholder grid or chart",
+			rows: [][]interface{}{
+				row("Overview", "KPIs", "KPI", "Spend", "", "", "Spend", "spend"),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Overview",
+				"DASHBOARD/Overview/KPIs",
+				"DASHBOARD/Overview/KPIs/KPI:Spend",
+			},
+		},
+		{
+			name: "new grid closes the last chart of the previous grid",
+			rows: [][]interface{}{
+				row("Overview", "KPIs", "KPI", "Spend"),
+				row("", "", "KPI", "Clicks"),
+				row("", "Trends", "Line", "Spend over time"),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Overview",
+				"DASHBOARD/Overview/KPIs",
+				"DASHBOARD/Overview/KPIs/KPI:Spend",
+				"DASHBOARD/Overview/KPIs/KPI:Clicks",
+				"DASHBOARD/Overview/Trends",
+				"DASHBOARD/Overview/Trends/Line:Spend over time",
+			},
+		},
+		{
+			name: "new tab closes the last grid and chart of the previous tab",
+			rows: [][]interface{}{
+				row("Overview", "KPIs", "KPI", "Spend"),
+				row("Channels", "Split", "Pie", "Spend by channel"),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Overview",
+				"DASHBOARD/Overview/KPIs",
+				"DASHBOARD/Overview/KPIs/KPI:Spend",
+				"DASHBOARD/Channels",
+				"DASHBOARD/Channels/Split",
+				"DASHBOARD/Channels/Split/Pie:Spend by channel",
+			},
+		},
+		{
+			name: "board change closes the tab into its own config",
+			rows: [][]interface{}{
+				row("Overview", "KPIs", "KPI", "Spend"),
+				row("Weekly Report", "Summary", "Table", "Campaigns"),
+				row("Channels", "Split", "Pie", "Spend by channel"),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Overview",
+				"DASHBOARD/Overview/KPIs",
+				"DASHBOARD/Overview/KPIs/KPI:Spend",
+				"DASHBOARD",
+				"DASHBOARD/Channels",
+				"DASHBOARD/Channels/Split",
+				"DASHBOARD/Channels/Split/Pie:Spend by channel",
+				"REPORT",
+				"REPORT/Weekly Report",
+				"REPORT/Weekly Report/Summary",
+				"REPORT/Weekly Report/Summary/Table:Campaigns",
+			},
+		},
+		{
+			name: "grid and tab without charts are kept",
+			rows: [][]interface{}{
+				row("Overview", "Empty grid"),
+				row("Empty tab"),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Overview",
+				"DASHBOARD/Overview/Empty grid",
+				"DASHBOARD/Empty tab",
+			},
+		},
+		{
+			name: "chart without a grid gets an untitled grid",
+			rows: [][]interface{}{
+				row("Weekly Report", "", "Table", "Campaigns"),
+				row("", "", "Table", "Ad groups"),
+			},
+			want: []string{
+				"REPORT",
+				"REPORT/Weekly Report",
+				"REPORT/Weekly Report/",
+				"REPORT/Weekly Report//Table:Campaigns",
+				"REPORT/Weekly Report//Table:Ad groups",
+			},
+		},
+		{
+			name: "board type column overrides the tab title",
+			rows: [][]interface{}{
+				row("Reporting KPIs", "KPIs", "KPI", "Spend", "", "", "", "", "Dashboard"),
+				row("Campaigns", "Summary", "Table", "Campaigns", "", "", "", "", "report"),
+				row("Monthly Report", "Summary", "Table", "Channels"),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Reporting KPIs",
+				"DASHBOARD/Reporting KPIs/KPIs",
+				"DASHBOARD/Reporting KPIs/KPIs/KPI:Spend",
+				"REPORT",
+				"REPORT/Campaigns",
+				"REPORT/Campaigns/Summary",
+				"REPORT/Campaigns/Summary/Table:Campaigns",
+				"REPORT/Monthly Report",
+				"REPORT/Monthly Report/Summary",
+				"REPORT/Monthly Report/Summary/Table:Channels",
+			},
+		},
+		{
+			name: "blank rows are skipped",
+			rows: [][]interface{}{
+				row(),
+				row("Overview", "KPIs", "KPI", "Spend"),
+				row(),
+				row("", "", "", ""),
+			},
+			want: []string{
+				"DASHBOARD",
+				"DASHBOARD/Overview",
+				"DASHBOARD/Overview/KPIs",
+				"DASHBOARD/Overview/KPIs/KPI:Spend",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse(tt.rows)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := outline(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outline:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	cfg, err := Parse([][]interface{}{
+		row("Overview", "Trends", "Line", "Spend vs clicks", "Date", "date", "Spend", "spend"),
+		row("", "", "", "", "", "", "Clicks", "clicks"),
+		row("", "", "Bar", "Spend by channel", "Channel", "channel", "Spend", "spend"),
+		row("", "", "", "", "Device", "device", "Clicks", "clicks"),
+	})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	charts := cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts
+	if len(charts) != 2 {
+		t.Fatalf("got %d charts, want 2", len(charts))
+	}
+
+	line := charts[0]
+	if want := []Metric{{ID: "spend", Name: "Spend"}}; !reflect.DeepEqual(line.LeftMetrics, want) {
+		t.Errorf("Line left metrics = %+v, want %+v", line.LeftMetrics, want)
+	}
+	if want := []Metric{{ID: "clicks", Name: "Clicks"}}; !reflect.DeepEqual(line.RightMetrics, want) {
+		t.Errorf("Line right metrics = %+v, want %+v", line.RightMetrics, want)
+	}
+
+	bar := charts[1]
+	if want := []Metric{{ID: "spend", Name: "Spend"}, {ID: "clicks", Name: "Clicks"}}; !reflect.DeepEqual(bar.LeftMetrics, want) {
+		t.Errorf("Bar left metrics = %+v, want %+v", bar.LeftMetrics, want)
+	}
+	if want := []Metric{{ID: "channel", Name: "Channel"}, {ID: "device", Name: "Device"}}; !reflect.DeepEqual(bar.Dimensions, want) {
+		t.Errorf("Bar dimensions = %+v, want %+v", bar.Dimensions, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([][]interface{}{
+		row("", "Orphan grid"),
+		row("Overview", "KPIs", "KPI", "Spend", "", "", "Spend"),
+		row("", "New grid", "", "", "", "", "Clicks", "clicks"),
+		row("", "", "", "Untyped", "", "date"),
+		row("Channels", "", "", "", "", "", "", "", "Board"),
+		row("", "", "", "", "", "", "", "", "Report"),
+	})
+
+	var list ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("Parse error = %v, want an ErrorList", err)
+	}
+	var got []string
+	for _, e := range list {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		"Sheet1!B4: row has content but no tab (A) has been started yet",
+		"Sheet1!G5: metric name present but metric ID (H) missing",
+		"Sheet1!C6: dimension or metric given but no chart is open in this grid",
+		"Sheet1!D7: chart title present but chart type (C) missing",
+		"Sheet1!E7: dimension ID present but dimension name (E) missing",
+		`Sheet1!I8: unknown board type "Board", want Dashboard or Report`,
+		"Sheet1!I9: board type given on a row that starts no tab (A)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors:\n got %s\nwant %s", strings.Join(got, "\n     "), strings.Join(want, "\n     "))
+	}
+}
+
+func TestStrictBoardType(t *testing.T) {
+	cfg, err := (&Parser{StrictBoardType: true}).Parse([][]interface{}{
+		row("Monthly Report", "Summary", "Table", "Channels"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Global.TemplateConfigs[0].BoardType; got != BoardTypeDashboard {
+		t.Errorf("board type = %s, want %s", got, BoardTypeDashboard)
+	}
+}
+
+func TestStableIDs(t *testing.T) {
+	rows := [][]interface{}{
+		row("Overview", "KPIs", "KPI", "Spend"),
+		row("", "", "KPI", "Spend"),
+	}
+	parser := &Parser{TemplateName: "Stable", IDs: IDStable}
+	first, err := parser.Parse(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := parser.Parse(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("stable IDs differ between runs")
+	}
+
+	charts := first.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts
+	if charts[0].TemplateChartID == charts[1].TemplateChartID {
+		t.Error("charts with the same title share an ID")
+	}
+
+	renamed, err := (&Parser{TemplateName: "Other", IDs: IDStable}).Parse(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Global.TemplateID == first.Global.TemplateID {
+		t.Error("templates with different names share an ID")
+	}
+}
+
+func TestPreviousIDs(t *testing.T) {
+	previous, err := Parse([][]interface{}{
+		row("Overview", "KPIs", "KPI", "Spend", "", "", "Spend", "spend"),
+		row("", "", "KPI", "Spend", "", "", "Clicks", "clicks"),
+		row("", "Trends", "Line", "Daily", "Date", "date", "Spend", "spend"),
+		row("Weekly Report", "Summary", "Table", "Totals", "", "", "Spend", "spend", "Report"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a tab and a chart inserted, a grid renamed, a metric changed
+	updated, err := (&Parser{Previous: &previous}).Parse([][]interface{}{
+		row("New Tab", "", "KPI", "Reach", "", "", "Reach", "reach"),
+		row("Overview", "KPIs", "KPI", "CTR", "", "", "CTR", "ctr"),
+		row("", "", "KPI", "Spend", "", "", "Cost", "spend"),
+		row("", "", "KPI", "Spend", "", "", "Clicks", "clicks"),
+		row("", "Daily Trends", "Line", "Daily", "Date", "date", "Spend", "spend"),
+		row("Weekly Report", "Summary", "Table", "Totals", "", "", "Spend", "spend", "Report"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Global.TemplateID != previous.Global.TemplateID {
+		t.Error("template ID not kept")
+	}
+	old := previous.Global.TemplateConfigs
+	got := updated.Global.TemplateConfigs
+	for i := range old {
+		if got[i].TemplateConfigID != old[i].TemplateConfigID {
+			t.Errorf("config %d ID not kept", i)
+		}
+	}
+	if got[0].Tabs[1].TemplateTabID != old[0].Tabs[0].TemplateTabID {
+		t.Error("tab ID not kept")
+	}
+	if got[0].Tabs[0].TemplateTabID == old[0].Tabs[0].TemplateTabID {
+		t.Error("new tab took the ID of the tab it was inserted before")
+	}
+	kpis := got[0].Tabs[1].Grids[0]
+	if kpis.TemplateGridID != old[0].Tabs[0].Grids[0].TemplateGridID {
+		t.Error("grid ID not kept")
+	}
+	for i, chart := range old[0].Tabs[0].Grids[0].Charts {
+		if kpis.Charts[i+1].TemplateChartID != chart.TemplateChartID {
+			t.Errorf("ID of chart %d not kept", i)
+		}
+	}
+	if kpis.Charts[0].TemplateChartID == old[0].Tabs[0].Grids[0].Charts[0].TemplateChartID {
+		t.Error("new chart took the ID of the chart it was inserted before")
+	}
+	if got[0].Tabs[1].Grids[1].TemplateGridID == old[0].Tabs[0].Grids[1].TemplateGridID {
+		t.Error("renamed grid kept its ID")
+	}
+	if got[1].Tabs[0].Grids[0].Charts[0].TemplateChartID != old[1].Tabs[0].Grids[0].Charts[0].TemplateChartID {
+		t.Error("report chart ID not kept")
+	}
+
+	// the stable ID A kept was made for its old path, the new A's path now
+	parser := &Parser{IDs: IDStable}
+	previous, err = parser.Parse([][]interface{}{
+		row("B", "", "KPI", "Spend", "", "", "Spend", "spend"),
+		row("A", "", "KPI", "Spend", "", "", "Spend", "spend"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Previous = &previous
+	updated, err = parser.Parse([][]interface{}{
+		row("A", "", "KPI", "Spend", "", "", "Spend", "spend"),
+		row("A", "", "KPI", "Spend", "", "", "Spend", "spend"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tabs := updated.Global.TemplateConfigs[0].Tabs
+	if tabs[0].TemplateTabID != previous.Global.TemplateConfigs[0].Tabs[1].TemplateTabID {
+		t.Error("stable tab ID not kept")
+	}
+	if tabs[1].TemplateTabID == tabs[0].TemplateTabID {
+		t.Errorf("both tabs A got ID %s", tabs[0].TemplateTabID)
+	}
+}
@@ -0,0 +1,229 @@
+package templategen
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// PatchOp is an operation of a JSON Patch (RFC 6902).
+type PatchOp struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// MarshalJSON writes the op with the members RFC 6902 gives it, so a null
+// or empty value is still written for add and replace.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	v := map[string]interface{}{"op": op.Op, "path": op.Path}
+	switch op.Op {
+	case "add", "replace", "test":
+		v["value"] = op.Value
+	case "move", "copy":
+		v["from"] = op.From
+	}
+	return json.Marshal(v)
+}
+
+// TemplatePatch returns the JSON Patch turning the JSON of old into that of
+// new, both written in the given schema version. Array elements are matched
+// by their ID, or failing that by title, so reordered tabs, grids, charts and
+// metrics give move operations rather than rewriting the arrays.
+func TemplatePatch(old, new GlobalTemplateConfig, schema int) ([]PatchOp, error) {
+	var docs [2]interface{}
+	for i, cfg := range []GlobalTemplateConfig{old, new} {
+		data, err := MarshalTemplate(cfg, schema)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &docs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return JSONPatch(docs[0], docs[1]), nil
+}
+
+// JSONPatch returns the JSON Patch turning the decoded JSON value a into b.
+func JSONPatch(a, b interface{}) []PatchOp {
+	var ops []PatchOp
+	diffJSON("", a, b, &ops)
+	return ops
+}
+
+func diffJSON(path string, a, b interface{}, ops *[]PatchOp) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffObject(path, a, b, ops)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			diffArray(path, a, b, ops)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*ops = append(*ops, PatchOp{Op: "replace", Path: path, Value: b})
+	}
+}
+
+func diffObject(path string, a, b map[string]interface{}, ops *[]PatchOp) {
+	for _, name := range sortedKeys(a) {
+		if vb, ok := b[name]; ok {
+			diffJSON(path+"/"+pointerEscape(name), a[name], vb, ops)
+		} else {
+			*ops = append(*ops, PatchOp{Op: "remove", Path: path + "/" + pointerEscape(name)})
+		}
+	}
+	for _, name := range sortedKeys(b) {
+		if _, ok := a[name]; !ok {
+			*ops = append(*ops, PatchOp{Op: "add", Path: path + "/" + pointerEscape(name), Value: b[name]})
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// patchIDKeys are the members identifying template objects, in the order
+// they are looked for.
+var patchIDKeys = []string{"template_config_id", "template_tab_id", "template_grid_id", "template_chart_id", "id"}
+
+// diffArray diffs arrays of objects by key when their elements have unique
+// keys, and by index otherwise.
+func diffArray(path string, a, b []interface{}, ops *[]PatchOp) {
+	keysA, keysB, ok := arrayKeys(a, b)
+	if !ok {
+		n := min(len(a), len(b))
+		for i := 0; i < n; i++ {
+			diffJSON(path+"/"+strconv.Itoa(i), a[i], b[i], ops)
+		}
+		for i := len(a) - 1; i >= n; i-- {
+			*ops = append(*ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := n; i < len(b); i++ {
+			*ops = append(*ops, PatchOp{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+		}
+		return
+	}
+
+	inB := make(map[string]bool, len(keysB))
+	for _, k := range keysB {
+		inB[k] = true
+	}
+	// cur follows the array as the ops so far leave it
+	cur := make([]string, 0, len(a))
+	vals := make([]interface{}, 0, len(a))
+	for i := len(a) - 1; i >= 0; i-- {
+		if !inB[keysA[i]] {
+			*ops = append(*ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+	}
+	for i := range a {
+		if inB[keysA[i]] {
+			cur = append(cur, keysA[i])
+			vals = append(vals, a[i])
+		}
+	}
+
+	for i, k := range keysB {
+		elem := path + "/" + strconv.Itoa(i)
+		j := i
+		for j < len(cur) && cur[j] != k {
+			j++
+		}
+		switch {
+		case j == len(cur):
+			*ops = append(*ops, PatchOp{Op: "add", Path: elem, Value: b[i]})
+			cur = insertAt(cur, i, k)
+			vals = insertAt(vals, i, b[i])
+			continue
+		case j != i:
+			*ops = append(*ops, PatchOp{Op: "move", From: path + "/" + strconv.Itoa(j), Path: elem})
+			v := vals[j]
+			cur = insertAt(append(cur[:j:j], cur[j+1:]...), i, k)
+			vals = insertAt(append(vals[:j:j], vals[j+1:]...), i, v)
+		}
+		diffJSON(elem, vals[i], b[i], ops)
+	}
+}
+
+func insertAt[T any](s []T, i int, v T) []T {
+	s = append(s, v)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// arrayKeys returns the keys of the elements of a and b: their IDs when the
+// arrays share any, or else their titles. ok is false when the elements are
+// not objects with unique keys.
+func arrayKeys(a, b []interface{}) (keysA, keysB []string, ok bool) {
+	keys := func(list []interface{}, member func(map[string]interface{}) string) ([]string, bool) {
+		out := make([]string, len(list))
+		seen := make(map[string]bool)
+		for i, v := range list {
+			obj, isObj := v.(map[string]interface{})
+			if !isObj {
+				return nil, false
+			}
+			k := member(obj)
+			if k == "" || seen[k] {
+				return nil, false
+			}
+			seen[k] = true
+			out[i] = k
+		}
+		return out, true
+	}
+	id := func(obj map[string]interface{}) string {
+		for _, name := range patchIDKeys {
+			if s, ok := obj[name].(string); ok && s != "" {
+				return name + "=" + s
+			}
+		}
+		return ""
+	}
+	title := func(obj map[string]interface{}) string {
+		var key bytes.Buffer
+		for _, name := range []string{"title", "chart_type", "board_type"} {
+			if s, ok := obj[name].(string); ok {
+				key.WriteString(name + "=" + s + ";")
+			}
+		}
+		return key.String()
+	}
+
+	keysA, okA := keys(a, id)
+	keysB, okB := keys(b, id)
+	if okA && okB && (len(a) == 0 || len(b) == 0 || shareAny(keysA, keysB)) {
+		return keysA, keysB, true
+	}
+	keysA, okA = keys(a, title)
+	keysB, okB = keys(b, title)
+	return keysA, keysB, okA && okB
+}
+
+func shareAny(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, k := range a {
+		set[k] = true
+	}
+	for _, k := range b {
+		if set[k] {
+			return true
+		}
+	}
+	return false
+}
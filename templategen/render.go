@@ -0,0 +1,413 @@
+package templategen
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Renderer writes a template back into template sheet rows, the reverse of
+// Parser: parsing the rows with Header set gives the template again.
+//
+// What the sheet layout has no place for is not written: IDs, which the
+// parser derives again (equal ones with IDStable and the same template
+// name), styling, which comes from themes, and dimension details, which come
+// from the catalog. Everything else that does not fit is reported, including
+// grids and charts whose styling is not that of the theme the rows will be
+// parsed with.
+type Renderer struct {
+	// GridLayout is the layout the rows will be parsed with. Charts of a grid
+	// it lays out the same way get no position columns; nil writes the
+	// position of every laid out chart.
+	GridLayout *GridLayout
+	// Themes and Theme are those the rows will be parsed with, see Parser.
+	// Without them any styling is reported.
+	Themes Themes
+	Theme  string
+}
+
+// Render renders cfg with a Renderer with default settings.
+func Render(cfg GlobalTemplateConfig) (Rows, Issues) {
+	return (&Renderer{}).Render(cfg)
+}
+
+// optionalColumns are the columns Render adds after those of DefaultLayout
+// when a template needs them, in this order.
+var optionalColumns = []Column{
+	ColGridOrder,
+	ColMetricPath, ColType, ColMetricGroup, ColMetricCategory, ColDataType,
+	ColMetricType, ColDescription, ColDivideByMillion, ColAggregationMethod,
+	ColX, ColY, ColWidth, ColHeight,
+}
+
+// renderedRow is a row being rendered, by column.
+type renderedRow map[Column]string
+
+// Render returns the rows of cfg at DefaultHeaderRange: a header row, then
+// the template rows. The first columns are those of DefaultLayout, so the
+// rows also parse without Header when the header row is skipped; optional
+// columns such as the metric details follow only when cfg uses them.
+//
+// Objects of cfg the rows cannot hold exactly are returned as warnings, with
+// the cell of the row they were written to.
+func (r *Renderer) Render(cfg GlobalTemplateConfig) (Rows, Issues) {
+	rng, _ := ParseRange(DefaultHeaderRange)
+	w := &rowWriter{}
+
+	// whether a config was written yet, and whether it was a report one; the
+	// parser reads the tabs of a config of the same board after it into it
+	written, lastReport := false, false
+	for _, config := range interleaveBoards(cfg.Global.TemplateConfigs) {
+		if config.TemplateConfigName != "" {
+			w.warn(ColTab, config.BoardType, "template config name %q is not written", config.TemplateConfigName)
+		}
+		if len(config.Tabs) == 0 {
+			continue
+		}
+		report := config.BoardType == BoardTypeReport
+		if written && report == lastReport {
+			board := "dashboard"
+			if report {
+				board = "report"
+			}
+			// on the row of the first tab, which comes next
+			w.issues = append(w.issues, renderIssue{
+				Issue: Issue{Severity: SeverityWarning, Path: config.BoardType,
+					Msg: fmt.Sprintf("%s config follows another with no config of the other board between them, they are read back as one", board)},
+				row: len(w.rows),
+				col: ColTab,
+			})
+		}
+		written, lastReport = true, report
+		for _, tab := range config.Tabs {
+			r.renderTab(w, config.BoardType, tab)
+		}
+	}
+
+	// the layout: DefaultLayout, then the optional columns in use
+	layout := make(Layout)
+	var header []interface{}
+	for c, i := range DefaultLayout {
+		layout[c] = i
+		for len(header) <= i {
+			header = append(header, "")
+		}
+		header[i] = string(c)
+	}
+	for _, c := range optionalColumns {
+		if w.used[c] {
+			layout[c] = len(header)
+			header = append(header, string(c))
+		}
+	}
+
+	rows := Rows{Sheet: rng.Sheet, StartRow: rng.StartRow, StartCol: rng.StartCol}
+	rows.Values = append(rows.Values, header)
+	for _, cells := range w.rows {
+		row := make([]interface{}, len(header))
+		for c, v := range cells {
+			row[layout[c]] = v
+		}
+		rows.Values = append(rows.Values, trimCells(row))
+	}
+
+	issues := make(Issues, len(w.issues))
+	for i, issue := range w.issues {
+		issues[i] = issue.Issue
+		issues[i].Location = Location{
+			Sheet: rows.Sheet,
+			Row:   rows.StartRow + 1 + issue.row,
+			Col:   rows.StartCol + layout[issue.col],
+		}
+	}
+	return rows, issues
+}
+
+func (r *Renderer) renderTab(w *rowWriter, board string, tab Tab) {
+	w.newRow()
+	w.set(ColTab, tab.Title)
+	w.set(ColBoardType, boardTypeCell(board))
+	if tab.Title == "" {
+		w.warn(ColTab, "", "untitled tab is not written")
+	}
+	if tab.SubTitle != "" {
+		w.warn(ColTab, tab.Title, "sub title %q is not written", tab.SubTitle)
+	}
+
+	for gi, grid := range tab.Grids {
+		path := tab.Title + " / " + grid.Title
+		// the first grid starts on the tab row
+		if gi > 0 {
+			w.newRow()
+		}
+		w.set(ColGrid, grid.Title)
+		// only a chart right under a tab gets an untitled grid
+		switch {
+		case grid.Title == "" && gi > 0:
+			w.warn(ColGrid, path, "untitled grid is merged into the one before it")
+		case grid.Title == "" && len(grid.Charts) == 0:
+			w.warn(ColGrid, path, "untitled empty grid is not written")
+		}
+		if grid.Position != 0 && grid.Position != gi+1 {
+			w.set(ColGridOrder, strconv.Itoa(grid.Position))
+		}
+		if grid.SubTitle != "" {
+			w.warn(ColGrid, path, "sub title %q is not written", grid.SubTitle)
+		}
+		theme, _ := r.Themes.lookup(r.themeName())
+		if !sameGridStyling(grid.Styling, theme.gridStyling()) {
+			w.warn(ColGrid, path, "styling %s", r.stylingLost())
+		}
+
+		positions := r.writePositions(grid)
+		for ci, chart := range grid.Charts {
+			if ci > 0 {
+				w.newRow()
+			}
+			if !sameChartStyling(chart.Styling, theme.chartStyling(chart.ChartType)) {
+				w.warn(ColChartType, path+" / "+chart.Title, "styling %s", r.stylingLost())
+			}
+			renderChart(w, path+" / "+chart.Title, chart, positions)
+		}
+	}
+}
+
+// themeName returns the name of the template theme, as Parser picks it.
+func (r *Renderer) themeName() string {
+	if r.Theme == "" {
+		return DefaultThemeName
+	}
+	return r.Theme
+}
+
+// stylingLost completes the warning for styling the rows lose.
+func (r *Renderer) stylingLost() string {
+	if _, ok := r.Themes.lookup(r.themeName()); ok {
+		return fmt.Sprintf("differs from theme %q and is not written", r.themeName())
+	}
+	return "is not written"
+}
+
+// sameGridStyling and sameChartStyling report whether a and b are equal,
+// taking a nil font format for an empty one as JSON may give either.
+func sameGridStyling(a, b GridStyling) bool {
+	for _, s := range []*GridStyling{&a, &b} {
+		s.TitleStyle.FontFormat = nilIfEmpty(s.TitleStyle.FontFormat)
+		s.SubTitleStyle.FontFormat = nilIfEmpty(s.SubTitleStyle.FontFormat)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func sameChartStyling(a, b ChartStyling) bool {
+	for _, s := range []*ChartStyling{&a, &b} {
+		s.TitleStyle.FontFormat = nilIfEmpty(s.TitleStyle.FontFormat)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func nilIfEmpty(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// renderChart writes chart starting at the current row, one row per
+// dimension and metric.
+func renderChart(w *rowWriter, path string, chart Chart, positions bool) {
+	w.set(ColChartType, chart.ChartType)
+	w.set(ColChartTitle, chart.Title)
+	if chart.ChartType == "" {
+		w.warn(ColChartType, path, "chart without a type is merged into the chart before it")
+	}
+	if chart.Source != "" {
+		w.warn(ColChartType, path, "source %q is not written", chart.Source)
+	}
+	if pos := chart.GridPosition; positions && pos.W > 0 && pos.H > 0 {
+		w.set(ColX, strconv.Itoa(pos.X))
+		w.set(ColY, strconv.Itoa(pos.Y))
+		w.set(ColWidth, strconv.Itoa(pos.W))
+		w.set(ColHeight, strconv.Itoa(pos.H))
+	}
+
+	// The parser puts the metric of the chart row on the left axis, and
+	// those of the rows below on the right axis of a Line chart.
+	var metrics []*Metric
+	if chart.ChartType == "Line" {
+		metrics = append(metrics, nil)
+		if len(chart.LeftMetrics) > 0 {
+			metrics[0] = &chart.LeftMetrics[0]
+		}
+		if len(chart.LeftMetrics) > 1 {
+			w.warn(ColMetricName, path, "Line chart has %d left metrics, all but the first are written as right metrics", len(chart.LeftMetrics))
+		}
+		for i := 1; i < len(chart.LeftMetrics); i++ {
+			metrics = append(metrics, &chart.LeftMetrics[i])
+		}
+	} else {
+		for i := range chart.LeftMetrics {
+			metrics = append(metrics, &chart.LeftMetrics[i])
+		}
+		if len(chart.RightMetrics) > 0 {
+			w.warn(ColMetricName, path, "%s chart has right metrics, they are written as left metrics", chart.ChartType)
+		}
+	}
+	for i := range chart.RightMetrics {
+		metrics = append(metrics, &chart.RightMetrics[i])
+	}
+
+	for i := 0; i < max(1, len(metrics), len(chart.Dimensions)); i++ {
+		if i > 0 {
+			w.newRow()
+		}
+		if i < len(chart.Dimensions) {
+			w.set(ColDimensionName, chart.Dimensions[i].Name)
+			w.set(ColDimensionID, chart.Dimensions[i].ID)
+		}
+		if i < len(metrics) && metrics[i] != nil {
+			renderMetric(w, *metrics[i])
+		}
+	}
+}
+
+func renderMetric(w *rowWriter, m Metric) {
+	w.set(ColMetricName, m.Name)
+	w.set(ColMetricID, m.ID)
+	w.set(ColMetricPath, m.Path)
+	w.set(ColType, m.Type)
+	w.set(ColMetricGroup, m.Group)
+	w.set(ColMetricCategory, m.Category)
+	w.set(ColDataType, m.DataType)
+	w.set(ColMetricType, m.MetricType)
+	w.set(ColDescription, m.Description)
+	if m.DivideByMillion {
+		w.set(ColDivideByMillion, "TRUE")
+	}
+	w.set(ColAggregationMethod, m.AggregationMethod)
+}
+
+// writePositions reports whether the charts of grid need their positions
+// written, i.e. whether the GridLayout would place them differently.
+func (r *Renderer) writePositions(grid Grid) bool {
+	if r.GridLayout == nil {
+		return true
+	}
+	packed := Grid{Charts: make([]Chart, len(grid.Charts))}
+	for i, chart := range grid.Charts {
+		packed.Charts[i] = Chart{ChartType: chart.ChartType}
+	}
+	r.GridLayout.placeGrid(&packed, map[string]chartHint{}, make(map[string]string))
+	for i := range grid.Charts {
+		if packed.Charts[i].GridPosition != grid.Charts[i].GridPosition {
+			return true
+		}
+	}
+	return false
+}
+
+// boardTypeCell returns the board type column value for board.
+func boardTypeCell(board string) string {
+	switch board {
+	case BoardTypeDashboard:
+		return "Dashboard"
+	case BoardTypeReport:
+		return "Report"
+	}
+	return board
+}
+
+// interleaveBoards orders configs so that the parser splits the rendered tabs
+// into the same configs: it starts a new config at every change of board,
+// and lists the dashboard configs before the report ones.
+func interleaveBoards(configs []TemplateConfigs) []TemplateConfigs {
+	var dashboards, reports []TemplateConfigs
+	for _, c := range configs {
+		if c.BoardType == BoardTypeReport {
+			reports = append(reports, c)
+		} else {
+			dashboards = append(dashboards, c)
+		}
+	}
+	first, second := dashboards, reports
+	if len(reports) > len(dashboards) {
+		first, second = reports, dashboards
+	}
+	out := make([]TemplateConfigs, 0, len(configs))
+	for i := range first {
+		out = append(out, first[i])
+		if i < len(second) {
+			out = append(out, second[i])
+		}
+	}
+	return append(out, second[min(len(first), len(second)):]...)
+}
+
+// rowWriter collects rendered rows and the problems found writing them.
+type rowWriter struct {
+	rows   []renderedRow
+	used   map[Column]bool
+	issues []renderIssue
+}
+
+// renderIssue is an Issue at a column of a rendered row, whose cell is known
+// once the layout is.
+type renderIssue struct {
+	Issue
+	row int
+	col Column
+}
+
+func (w *rowWriter) newRow() {
+	w.rows = append(w.rows, make(renderedRow))
+}
+
+func (w *rowWriter) row() renderedRow {
+	if len(w.rows) == 0 {
+		w.newRow()
+	}
+	return w.rows[len(w.rows)-1]
+}
+
+// set writes a non-empty value into column c of the current row.
+func (w *rowWriter) set(c Column, v string) {
+	if v == "" {
+		return
+	}
+	if w.used == nil {
+		w.used = make(map[Column]bool)
+	}
+	w.used[c] = true
+	w.row()[c] = v
+}
+
+func (w *rowWriter) warn(c Column, path, format string, args ...interface{}) {
+	w.issues = append(w.issues, renderIssue{
+		Issue: Issue{Severity: SeverityWarning, Path: path, Msg: fmt.Sprintf(format, args...)},
+		row:   max(len(w.rows)-1, 0),
+		col:   c,
+	})
+}
+
+// trimCells drops the trailing empty cells of row, as the Sheets API does.
+func trimCells(row []interface{}) []interface{} {
+	end := len(row)
+	for end > 0 && (row[end-1] == nil || row[end-1] == "") {
+		end--
+	}
+	for i := range row[:end] {
+		if row[i] == nil {
+			row[i] = ""
+		}
+	}
+	return row[:end]
+}
+
+// Range returns the open-ended A1 range holding rows, e.g. "Sheet1!A3:N",
+// to read them back with.
+func (r Rows) Range() string {
+	width := 1
+	for _, row := range r.Values {
+		width = max(width, len(row))
+	}
+	return CellRange{Sheet: r.Sheet, StartRow: r.StartRow, StartCol: r.StartCol, EndCol: r.StartCol + width - 1}.String()
+}
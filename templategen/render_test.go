@@ -0,0 +1,198 @@
+package templategen
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRenderRoundTrip renders the template of every fixture and parses the
+// rows again, through CSV and XLSX, expecting the same template back.
+func TestRenderRoundTrip(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".csv")
+		t.Run(name, func(t *testing.T) {
+			rows, err := (&CSVSource{Path: fixture, HeaderRows: DefaultHeaderRows}).ReadRows(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			layout := &GridLayout{}
+			want, _ := (&Parser{TemplateName: name, IDs: IDStable, GridLayout: layout}).ParseRows(rows)
+
+			rendered, issues := (&Renderer{GridLayout: layout}).Render(want)
+			for _, issue := range issues {
+				t.Errorf("render: %s", issue)
+			}
+
+			var csvData, xlsxData bytes.Buffer
+			if err := WriteCSV(&csvData, rendered); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteXLSX(&xlsxData, rendered, ""); err != nil {
+				t.Fatal(err)
+			}
+			fromCSV, err := ReadCSV(&csvData, DefaultHeaderRows-1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fromXLSX, err := ReadXLSX(&xlsxData, rendered.Range())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for source, values := range map[string][][]interface{}{"rows": rendered.Values, "CSV": fromCSV, "XLSX": fromXLSX} {
+				got, err := (&Parser{TemplateName: name, IDs: IDStable, GridLayout: layout, Header: true}).ParseRows(
+					Rows{Sheet: rendered.Sheet, StartRow: rendered.StartRow, StartCol: rendered.StartCol, Values: values})
+				if err != nil {
+					t.Errorf("%s: %v", source, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: template changed in the round trip", source)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDetails(t *testing.T) {
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Tab", "Grid", "Chart Type", "Chart Title", "Dimension", "Dimension ID", "Metric", "Metric ID", "Board Type", "Data Type", "Divide By Million", "X", "Y"),
+			row("Overview", "Trends", "Line", "Spend", "Date", "date", "Spend", "spend", "", "currency", "TRUE", "6", "0"),
+			row("", "", "", "", "", "", "Clicks", "clicks"),
+			row("", "", "KPI", "CTR", "", "", "CTR", "ctr"),
+			row("Weekly Report", "Summary", "Table", "Spend", "", "", "Spend", "spend"),
+		},
+	}
+	layout := &GridLayout{}
+	parser := &Parser{Header: true, IDs: IDStable, GridLayout: layout}
+	want, err := parser.ParseRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, issues := (&Renderer{GridLayout: layout}).Render(want)
+	if len(issues) > 0 {
+		t.Errorf("issues = %v", issues)
+	}
+	wantRows := [][]interface{}{
+		row("Tab", "Grid", "Chart Type", "Chart Title", "Dimension", "Dimension ID", "Metric", "Metric ID", "Board Type",
+			"Data Type", "Divide By Million", "X", "Y", "Width", "Height"),
+		row("Overview", "Trends", "Line", "Spend", "Date", "date", "Spend", "spend", "Dashboard", "currency", "TRUE", "6", "0", "6", "4"),
+		row("", "", "", "", "", "", "Clicks", "clicks"),
+		row("", "", "KPI", "CTR", "", "", "CTR", "ctr", "", "", "", "0", "0", "3", "2"),
+		row("Weekly Report", "Summary", "Table", "Spend", "", "", "Spend", "spend", "Report"),
+	}
+	if !reflect.DeepEqual(rendered.Values, wantRows) {
+		t.Errorf("rows:\n got %q\nwant %q", rendered.Values, wantRows)
+	}
+	if got, err := parser.ParseRows(rendered); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("template changed in the round trip (error %v)", err)
+	}
+	// the default range reaches the columns written past J
+	var xlsxData bytes.Buffer
+	if err := WriteXLSX(&xlsxData, rendered, ""); err != nil {
+		t.Fatal(err)
+	}
+	if values, err := ReadXLSX(&xlsxData, DefaultHeaderRange); err != nil || !reflect.DeepEqual(values, rendered.Values) {
+		t.Errorf("read back at %s: %q (error %v)", DefaultHeaderRange, values, err)
+	}
+
+	chart := &want.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[1]
+	chart.RightMetrics = []Metric{{ID: "cpc", Name: "CPC"}}
+	chart.Source = "ads"
+	chart.Styling.Palette = 4
+	_, issues = (&Renderer{GridLayout: layout}).Render(want)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	wantIssues := []string{
+		"warning: Sheet1!C6: Overview / Trends / CTR: styling is not written",
+		`warning: Sheet1!C6: Overview / Trends / CTR: source "ads" is not written`,
+		"warning: Sheet1!G6: Overview / Trends / CTR: KPI chart has right metrics, they are written as left metrics",
+	}
+	if !reflect.DeepEqual(got, wantIssues) {
+		t.Errorf("issues:\n got %q\nwant %q", got, wantIssues)
+	}
+
+	// with the theme giving that styling, the other charts lose theirs
+	chart.RightMetrics, chart.Source = nil, ""
+	themes := Themes{"default": {Name: "default", Chart: ChartStyling{Palette: 4}}}
+	_, issues = (&Renderer{GridLayout: layout, Themes: themes}).Render(want)
+	got = nil
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	wantIssues = []string{
+		`warning: Sheet1!C4: Overview / Trends / Spend: styling differs from theme "default" and is not written`,
+		`warning: Sheet1!C7: Weekly Report / Summary / Spend: styling differs from theme "default" and is not written`,
+	}
+	if !reflect.DeepEqual(got, wantIssues) {
+		t.Errorf("issues with a theme:\n got %q\nwant %q", got, wantIssues)
+	}
+
+	// two dashboard configs without a report one to put between them
+	chart.Styling.Palette = 0
+	dashboard := want.Global.TemplateConfigs[0]
+	want.Global.TemplateConfigs = []TemplateConfigs{dashboard, dashboard}
+	_, issues = (&Renderer{GridLayout: layout}).Render(want)
+	got = nil
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	wantIssues = []string{
+		"warning: Sheet1!A7: DASHBOARD: dashboard config follows another with no config of the other board between them, they are read back as one",
+	}
+	if !reflect.DeepEqual(got, wantIssues) {
+		t.Errorf("issues with two dashboard configs:\n got %q\nwant %q", got, wantIssues)
+	}
+}
+
+// TestXLSXWriteRows writes rows into an existing workbook, expecting its
+// other sheets and the cells above the range to survive.
+func TestXLSXWriteRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	f, err := newWorkbook("Notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetCellValue("Notes", "A1", "keep")
+	f.NewSheet("Template")
+	f.SetCellValue("Template", "A1", "title")
+	f.SetCellValue("Template", "A3", "stale")
+	f.SetCellValue("Template", "D9", "stale")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ctx := context.Background()
+	rows := Rows{Values: [][]interface{}{row("Tab", "Grid"), row("Overview", "KPIs")}}
+	if err := (&XLSXSource{Path: path, Range: "Template!A3"}).WriteRows(ctx, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	for cellRange, want := range map[string][][]interface{}{
+		"Notes!A1:B":    {row("keep")},
+		"Template!A1:J": {row("title"), {}, row("Tab", "Grid"), row("Overview", "KPIs")},
+	} {
+		got, err := (&XLSXSource{Path: path, Range: cellRange}).ReadRows(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Values, want) {
+			t.Errorf("%s = %#v, want %#v", cellRange, got.Values, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package templategen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Schema versions of the template JSON.
+const (
+	// SchemaLegacy is the original shape, with field names mixing snake_case
+	// and camelCase and the chart title alignment written as "Alignment".
+	// Importers written against it can keep reading it.
+	SchemaLegacy = 1
+	// SchemaV2 names every field in snake_case, as the struct tags of
+	// GlobalTemplateConfig do, and records its version in schema_version.
+	SchemaV2 = 2
+
+	// SchemaVersion is the version written by default.
+	SchemaVersion = SchemaV2
+)
+
+// ParseSchema checks a schema version given on the command line or in a
+// config file; "legacy" names SchemaLegacy.
+func ParseSchema(s string) (int, error) {
+	switch s {
+	case "1", "legacy":
+		return SchemaLegacy, nil
+	case "2":
+		return SchemaV2, nil
+	}
+	return 0, fmt.Errorf("unknown schema version %q, want 1 (legacy) or 2", s)
+}
+
+// document is the top level of the template JSON from SchemaV2 on.
+type document struct {
+	SchemaVersion int `json:"schema_version"`
+	GlobalTemplateConfig
+}
+
+// MarshalTemplate returns cfg as indented JSON in the given schema version,
+// ending in a newline.
+func MarshalTemplate(cfg GlobalTemplateConfig, schema int) ([]byte, error) {
+	var v interface{}
+	switch schema {
+	case SchemaLegacy:
+		v = toLegacy(cfg)
+	case SchemaV2:
+		v = document{SchemaVersion: schema, GlobalTemplateConfig: cfg}
+	default:
+		return nil, fmt.Errorf("unknown schema version %d", schema)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalTemplate reads template JSON of any schema version. Documents
+// without a schema_version are read as SchemaLegacy.
+func UnmarshalTemplate(data []byte) (GlobalTemplateConfig, error) {
+	var probe struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return GlobalTemplateConfig{}, err
+	}
+
+	switch probe.SchemaVersion {
+	case 0:
+		var legacy legacyConfig
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return GlobalTemplateConfig{}, err
+		}
+		return fromLegacy(legacy), nil
+	case SchemaV2:
+		var doc document
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return GlobalTemplateConfig{}, err
+		}
+		return doc.GlobalTemplateConfig, nil
+	}
+	return GlobalTemplateConfig{}, fmt.Errorf("unsupported schema version %d", probe.SchemaVersion)
+}
+
+// ReadTemplate is UnmarshalTemplate for a reader.
+func ReadTemplate(r io.Reader) (GlobalTemplateConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return GlobalTemplateConfig{}, err
+	}
+	return UnmarshalTemplate(data)
+}
@@ -0,0 +1,124 @@
+package templategen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRoundTrip(t *testing.T) {
+	cfg, err := Parse([][]interface{}{
+		row("Overview", "KPIs", "KPI", "Spend", "", "", "Spend", "spend"),
+		row("", "Trends", "Line", "Spend", "Date", "date", "Spend", "spend"),
+		row("", "", "", "", "", "", "Clicks", "clicks"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chart := &cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0]
+	chart.Styling.TitleStyle = ChartFontStyle{Font: "Inter", FontSize: 14, FontFormat: []string{"bold"}, Alignment: "center"}
+	chart.GridPosition = GridPos{W: 3, H: 2, MaxH: 4}
+
+	for _, schema := range []int{SchemaLegacy, SchemaV2} {
+		data, err := MarshalTemplate(cfg, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalTemplate(data)
+		if err != nil {
+			t.Fatalf("schema %d: %v", schema, err)
+		}
+		if !reflect.DeepEqual(got, cfg) {
+			t.Errorf("schema %d: round trip changed the template:\n got %+v\nwant %+v", schema, got, cfg)
+		}
+
+		legacyKeys := []string{`"Alignment"`, `"fontSize"`, `"maxH"`, `"titleStyle"`}
+		for _, key := range legacyKeys {
+			if has := strings.Contains(string(data), key); has != (schema == SchemaLegacy) {
+				t.Errorf("schema %d: has key %s = %v", schema, key, has)
+			}
+		}
+	}
+
+	if _, err := UnmarshalTemplate([]byte(`{"schema_version": 9}`)); err == nil {
+		t.Error("UnmarshalTemplate accepted an unknown schema version")
+	}
+}
+
+// TestPublishedSchemas checks the schema files in the schema directory
+// against TemplateSchema. Run with -update to regenerate them.
+func TestPublishedSchemas(t *testing.T) {
+	for _, schema := range []int{SchemaLegacy, SchemaV2} {
+		s, err := TemplateSchema(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, '\n')
+		compareGolden(t, filepath.Join("..", "schema", fmt.Sprintf("template-v%d.schema.json", schema)), data)
+	}
+}
+
+// TestValidateBaseline checks a document written by the generator before
+// SchemaV2, empty grids and charts included, against the legacy schema.
+func TestValidateBaseline(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "baseline.legacy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateTemplate(data, SchemaLegacy); err != nil {
+		t.Errorf("ValidateTemplate: %v", err)
+	}
+	if _, err := UnmarshalTemplate(data); err != nil {
+		t.Errorf("UnmarshalTemplate: %v", err)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	doc := `{
+  "schema_version": 2,
+  "global": {
+    "template_id": "",
+    "template_name": "x",
+    "template_configs": [
+      {
+        "template_config_name": "",
+        "template_type": "TAB_GRID_CHART",
+        "board_type": "BOARD",
+        "template_config_id": "c",
+        "tabs": null,
+        "extra": true
+      }
+    ]
+  }
+}`
+	err := ValidateTemplate([]byte(doc), SchemaV2)
+	list, ok := err.(SchemaErrors)
+	if !ok {
+		t.Fatalf("ValidateTemplate error = %v", err)
+	}
+	var got []string
+	for _, e := range list {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		`/global/template_configs/0/board_type: got "BOARD", want one of DASHBOARD, REPORT`,
+		"/global/template_configs/0/extra: not allowed",
+		"/global/template_id: must be at least 1 characters long",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors:\n got %q\nwant %q", got, want)
+	}
+
+	if err := ValidateTemplate([]byte(`{"schema_version": 1, "global": {}}`), SchemaV2); err == nil ||
+		!strings.Contains(err.Error(), "/schema_version: got 1, want 2") {
+		t.Errorf("schema version error = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package templategen
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// NewSheetsService creates a Sheets API client authenticated with the given
+// service account credentials file.
+func NewSheetsService(ctx context.Context, credentialsFile string) (*sheets.Service, error) {
+	service, err := sheets.NewService(ctx, option.WithCredentialsFile(credentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Sheets service: %w", err)
+	}
+	return service, nil
+}
+
+// SheetsSource reads the template rows from a Google Sheet.
+type SheetsSource struct {
+	Service       *sheets.Service
+	SpreadsheetID string
+	// Range is the A1 range to read, DefaultRange when empty.
+	Range string
+}
+
+func (s *SheetsSource) ReadRows(ctx context.Context) (Rows, error) {
+	readRange := s.Range
+	if readRange == "" {
+		readRange = DefaultRange
+	}
+	data, err := s.Service.Spreadsheets.Values.Get(s.SpreadsheetID, readRange).Context(ctx).Do()
+	if err != nil {
+		return Rows{}, fmt.Errorf("unable to retrieve data from Google Sheet: %w", err)
+	}
+
+	// the returned range always names the sheet, unlike a requested "A4:J"
+	rng, err := ParseRange(data.Range)
+	if err != nil {
+		if rng, err = ParseRange(readRange); err != nil {
+			return Rows{}, err
+		}
+	}
+	return Rows{
+		Sheet:    rng.Sheet,
+		StartRow: rng.StartRow,
+		StartCol: rng.StartCol,
+		Values:   data.Values,
+	}, nil
+}
+
+// WriteRows writes rows to the Google Sheet, in the sheet and at the top-left
+// cell of Range, or at their own when Range is empty. The cells from there to
+// the right and down are cleared first.
+func (s *SheetsSource) WriteRows(ctx context.Context, rows Rows) error {
+	rows, err := rows.at(s.Range)
+	if err != nil {
+		return err
+	}
+	start := CellRange{Sheet: rows.Sheet, StartRow: rows.StartRow, StartCol: rows.StartCol}
+	clear := start
+	clear.EndCol = clearColumns
+	if _, err := s.Service.Spreadsheets.Values.Clear(s.SpreadsheetID, clear.String(), &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("unable to clear Google Sheet range %s: %w", clear, err)
+	}
+
+	values := &sheets.ValueRange{MajorDimension: "ROWS", Values: rows.Values}
+	if _, err := s.Service.Spreadsheets.Values.Update(s.SpreadsheetID, start.String(), values).ValueInputOption("RAW").Context(ctx).Do(); err != nil {
+		return fmt.Errorf("unable to write to Google Sheet: %w", err)
+	}
+	return nil
+}
+
+// clearColumns is the width cleared by SheetsSource.WriteRows and
+// XLSXSource.WriteRows, column ZZ.
+const clearColumns = 26 * 27
@@ -0,0 +1,142 @@
+package templategen
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultRange is the part of the template sheet holding the template rows.
+const DefaultRange = "Sheet1!A4:J"
+
+// DefaultHeaderRange is DefaultRange together with the header row above it,
+// for use with Parser.Header. It reaches to column ZZ, as far as rows are
+// cleared on write, since a header may name columns in any order and place.
+const DefaultHeaderRange = "Sheet1!A3:ZZ"
+
+// Rows are template rows together with where they were read from.
+type Rows struct {
+	// Sheet names the worksheet or file the rows come from.
+	Sheet string
+	// StartRow and StartCol are the 1-based sheet coordinates of Values[0][0].
+	StartRow int
+	StartCol int
+	Values   [][]interface{}
+}
+
+// ReadRows returns r itself, so fixed rows can be used as a RowSource.
+func (r Rows) ReadRows(context.Context) (Rows, error) {
+	return r, nil
+}
+
+// RowSource is an input the template rows can be read from.
+type RowSource interface {
+	ReadRows(ctx context.Context) (Rows, error)
+}
+
+// SourceOptions are the settings used by OpenSource when the source URI does
+// not give them itself.
+type SourceOptions struct {
+	// CredentialsFile is the service account file for gsheet:// sources.
+	CredentialsFile string
+	// Range is the A1 range read from Google Sheets and XLSX workbooks.
+	Range string
+	// HeaderRows is the number of lines skipped at the top of CSV files.
+	HeaderRows int
+}
+
+// RowSink is an output template rows can be written to, see Renderer.
+type RowSink interface {
+	WriteRows(ctx context.Context, rows Rows) error
+}
+
+// rowStore is a sheet template rows can be both read from and written to.
+type rowStore interface {
+	RowSource
+	RowSink
+}
+
+// OpenSource returns the RowSource described by uri:
+//
+//	gsheet://<spreadsheet ID>[?range=Sheet1!A4:J]
+//	file://<path>.csv[?skip=3]
+//	file://<path>.xlsx[?range=Sheet1!A4:J]
+//
+// A plain path ending in .csv or .xlsx is treated as a file:// URI.
+func OpenSource(ctx context.Context, uri string, opts SourceOptions) (RowSource, error) {
+	if opts.Range == "" {
+		opts.Range = DefaultRange
+	}
+	return openStore(ctx, uri, opts)
+}
+
+// OpenSink returns the RowSink described by uri, which takes the same forms
+// as for OpenSource. The rows are written at the start of the range, by
+// default at DefaultHeaderRange; CSV files get the rows at their own line.
+func OpenSink(ctx context.Context, uri string, opts SourceOptions) (RowSink, error) {
+	if opts.Range == "" {
+		opts.Range = DefaultHeaderRange
+	}
+	return openStore(ctx, uri, opts)
+}
+
+func openStore(ctx context.Context, uri string, opts SourceOptions) (rowStore, error) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		scheme, rest = "file", uri
+	}
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source %q: %w", uri, err)
+	}
+	if query.Has("range") {
+		opts.Range = query.Get("range")
+	}
+	if query.Has("skip") {
+		if opts.HeaderRows, err = strconv.Atoi(query.Get("skip")); err != nil {
+			return nil, fmt.Errorf("invalid source %q: skip must be a number", uri)
+		}
+	}
+
+	switch scheme {
+	case "gsheet":
+		if rest == "" {
+			return nil, fmt.Errorf("invalid source %q: missing spreadsheet ID", uri)
+		}
+		service, err := NewSheetsService(ctx, opts.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		return &SheetsSource{Service: service, SpreadsheetID: rest, Range: opts.Range}, nil
+	case "file":
+		switch strings.ToLower(filepath.Ext(rest)) {
+		case ".csv":
+			return &CSVSource{Path: rest, HeaderRows: opts.HeaderRows}, nil
+		case ".xlsx":
+			return &XLSXSource{Path: rest, Range: opts.Range}, nil
+		}
+		return nil, fmt.Errorf("invalid source %q: unsupported file type", uri)
+	}
+	return nil, fmt.Errorf("invalid source %q: unknown scheme %q", uri, scheme)
+}
+
+// at returns rows moved to the sheet and top-left cell of cellRange; an
+// empty cellRange or one without a sheet name keeps those of rows.
+func (r Rows) at(cellRange string) (Rows, error) {
+	if cellRange == "" {
+		return r, nil
+	}
+	rng, err := ParseRange(cellRange)
+	if err != nil {
+		return Rows{}, err
+	}
+	if rng.Sheet != "" {
+		r.Sheet = rng.Sheet
+	}
+	r.StartRow, r.StartCol = rng.StartRow, rng.StartCol
+	return r, nil
+}
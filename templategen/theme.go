@@ -0,0 +1,187 @@
+package templategen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultThemeName is the theme applied when a Parser names none and its
+// themes include one of this name.
+const DefaultThemeName = "default"
+
+// Theme is a named styling preset for the grids and charts of a template.
+//
+// A theme file is a JSON object with the field names of the template output,
+// of SchemaV2 or of SchemaLegacy ("titleStyle", "chartTypes", ...):
+//
+//	{
+//	  "name": "brand",
+//	  "grid": {"title_style": {"font": "Inter", "font_size": 18}},
+//	  "chart": {"palette": 2, "legend_position": "bottom"},
+//	  "chart_types": {"Table": {"table_style": {"table_header": {"font": "Inter"}}}}
+//	}
+type Theme struct {
+	Name  string       `json:"name"`
+	Grid  GridStyling  `json:"grid"`
+	Chart ChartStyling `json:"chart"`
+	// ChartTypes replace Chart for the chart types they list.
+	ChartTypes map[string]ChartStyling `json:"chart_types,omitempty"`
+}
+
+// chartStyling returns the styling of a chart of the given type.
+func (t Theme) chartStyling(chartType string) ChartStyling {
+	s, ok := t.ChartTypes[chartType]
+	if !ok {
+		s = t.Chart
+	}
+	s.TitleStyle.FontFormat = cloneStrings(s.TitleStyle.FontFormat)
+	return s
+}
+
+func (t Theme) gridStyling() GridStyling {
+	s := t.Grid
+	s.TitleStyle.FontFormat = cloneStrings(s.TitleStyle.FontFormat)
+	s.SubTitleStyle.FontFormat = cloneStrings(s.SubTitleStyle.FontFormat)
+	return s
+}
+
+// Themes holds the themes a template can use, keyed by name.
+type Themes map[string]Theme
+
+// LoadThemes reads theme files. A path naming a directory loads every .json
+// file in it. A theme without a name is named after its file.
+func LoadThemes(paths ...string) (Themes, error) {
+	themes := make(Themes)
+	for _, path := range paths {
+		files := []string{path}
+		if info, err := os.Stat(path); err != nil {
+			return nil, err
+		} else if info.IsDir() {
+			if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+				return nil, err
+			}
+			sort.Strings(files)
+		}
+		for _, file := range files {
+			theme, err := loadTheme(file)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := themes.lookup(theme.Name); dup {
+				return nil, fmt.Errorf("%s: theme %q defined twice", file, theme.Name)
+			}
+			themes[theme.Name] = theme
+		}
+	}
+	return themes, nil
+}
+
+func loadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	var theme Theme
+	var legacy legacyTheme
+	isLegacy, err := unmarshalEither(data, &theme, &legacy)
+	if err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if isLegacy {
+		theme = legacy.theme()
+	}
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return theme, nil
+}
+
+// legacyTheme is a Theme with the field names of SchemaLegacy, as theme
+// files were written before SchemaV2.
+type legacyTheme struct {
+	Name       string                        `json:"name"`
+	Grid       legacyGridStyling             `json:"grid"`
+	Chart      legacyChartStyling            `json:"chart"`
+	ChartTypes map[string]legacyChartStyling `json:"chartTypes"`
+}
+
+func (t legacyTheme) theme() Theme {
+	theme := Theme{
+		Name:  t.Name,
+		Grid:  fromLegacyGridStyling(t.Grid),
+		Chart: fromLegacyChartStyling(t.Chart),
+	}
+	for chartType, s := range t.ChartTypes {
+		if theme.ChartTypes == nil {
+			theme.ChartTypes = make(map[string]ChartStyling)
+		}
+		theme.ChartTypes[chartType] = fromLegacyChartStyling(s)
+	}
+	return theme
+}
+
+// lookup returns the theme called name, ignoring case.
+func (t Themes) lookup(name string) (Theme, bool) {
+	if theme, ok := t[name]; ok {
+		return theme, true
+	}
+	for key, theme := range t {
+		if strings.EqualFold(key, name) {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+// names returns the theme names in order, for messages.
+func (t Themes) names() string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// applyThemes styles the grids and charts of cfg. Each object uses the theme
+// chosen for it in choices (by ID), or else the theme of its parent; tabs
+// fall back to the template theme def. Objects left without a theme keep
+// their styling.
+func applyThemes(cfg *GlobalTemplateConfig, themes Themes, def string, choices map[string]string) {
+	pick := func(id, parent string) string {
+		if name, ok := choices[id]; ok {
+			return name
+		}
+		return parent
+	}
+	for ci := range cfg.Global.TemplateConfigs {
+		config := &cfg.Global.TemplateConfigs[ci]
+		for ti := range config.Tabs {
+			tab := &config.Tabs[ti]
+			tabTheme := pick(tab.TemplateTabID, def)
+			for gi := range tab.Grids {
+				grid := &tab.Grids[gi]
+				gridTheme := pick(grid.TemplateGridID, tabTheme)
+				if theme, ok := themes.lookup(gridTheme); ok {
+					grid.Styling = theme.gridStyling()
+				}
+				for i := range grid.Charts {
+					chart := &grid.Charts[i]
+					if theme, ok := themes.lookup(pick(chart.TemplateChartID, gridTheme)); ok {
+						chart.Styling = theme.chartStyling(chart.ChartType)
+					}
+				}
+			}
+		}
+	}
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
@@ -0,0 +1,108 @@
+package templategen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadThemes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"brand.json": `{"grid": {"title_style": {"font": "Inter", "font_size": 18}}, "chart": {"palette": 2}}`,
+		"dark.json":  `{"name": "Dark", "chart": {"palette": 5, "legend_position": "bottom"}}`,
+		// written before schema v2
+		"old.json": `{"chart": {"legendPosition": "top"}, "chartTypes": {"Table": {"tableStyle": {"tableHeader": {"fontSize": 9}}}}}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	themes, err := LoadThemes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := themes.names(); got != "Dark, brand, old" {
+		t.Errorf("names = %q", got)
+	}
+	if theme, ok := themes.lookup("BRAND"); !ok || theme.Grid.TitleStyle.FontSize != 18 {
+		t.Errorf("lookup(BRAND) = %+v, %v", theme, ok)
+	}
+	if old := themes["old"]; old.Chart.LegendPosition != "top" || old.ChartTypes["Table"].TableStyle.TableHeader.FontSize != 9 {
+		t.Errorf("legacy theme = %+v", old)
+	}
+
+	mixed := filepath.Join(t.TempDir(), "mixed.json")
+	if err := os.WriteFile(mixed, []byte(`{"chart": {"legend_position": "top"}, "chartTypes": {"Table": {"palette": 3}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadThemes(mixed); err == nil {
+		t.Error("LoadThemes of a theme mixing field names: no error")
+	}
+}
+
+func TestParseThemes(t *testing.T) {
+	themes := Themes{
+		"default": {Name: "default", Chart: ChartStyling{Palette: 1}},
+		"brand": {
+			Name:       "brand",
+			Grid:       GridStyling{TitleStyle: GridFontStyle{Font: "Inter", FontFormat: []string{"bold"}}},
+			Chart:      ChartStyling{Palette: 2, LegendPosition: "right"},
+			ChartTypes: map[string]ChartStyling{"Table": {Palette: 3}},
+		},
+	}
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 3,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Tab", "Grid", "Chart Type", "Chart Title", "Metric", "Metric ID", "Theme"),
+			row("Overview", "KPIs", "KPI", "Spend", "Spend", "spend", "brand"),
+			row("", "", "KPI", "Clicks", "Clicks", "clicks"),
+			row("Details", "", "", "", "", "", "brand"),
+			row("", "Tables", "Table", "Spend", "Spend", "spend"),
+			row("", "", "", "", "", "", "brand"),
+			row("", "", "Line", "CTR", "CTR", "ctr", "neon"),
+		},
+	}
+	cfg, err := (&Parser{Header: true, Themes: themes}).ParseRows(rows)
+
+	list, _ := err.(ErrorList)
+	var got []string
+	for _, e := range list {
+		got = append(got, e.Error())
+	}
+	wantErrs := []string{
+		"Sheet1!G8: theme given on a row that starts no tab, grid or chart",
+		`Sheet1!G9: unknown theme "neon", want one of brand, default`,
+	}
+	if !reflect.DeepEqual(got, wantErrs) {
+		t.Errorf("errors = %q, want %q", got, wantErrs)
+	}
+
+	var palettes []int
+	tabs := cfg.Global.TemplateConfigs[0].Tabs
+	for _, tab := range tabs {
+		for _, grid := range tab.Grids {
+			for _, chart := range grid.Charts {
+				palettes = append(palettes, chart.Styling.Palette)
+			}
+		}
+	}
+	// chart theme, default theme, tab theme by chart type, tab theme
+	if want := []int{2, 1, 3, 2}; !reflect.DeepEqual(palettes, want) {
+		t.Errorf("palettes = %v, want %v", palettes, want)
+	}
+	if grid := tabs[0].Grids[0]; grid.Styling.TitleStyle.Font != "" {
+		t.Errorf("grid of a default tab got font %q", grid.Styling.TitleStyle.Font)
+	}
+	if grid := tabs[1].Grids[0]; grid.Styling.TitleStyle.Font != "Inter" {
+		t.Errorf("grid of a brand tab got font %q", grid.Styling.TitleStyle.Font)
+	}
+
+	if _, err := (&Parser{Themes: themes, Theme: "neon"}).ParseRows(Rows{}); err == nil || err.Error() != `unknown theme "neon"` {
+		t.Errorf("unknown template theme error = %v", err)
+	}
+}
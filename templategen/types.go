@@ -0,0 +1,109 @@
+package templategen
+
+type GlobalTemplateConfig struct {
+	Global Global `json:"global"`
+}
+
+type Global struct {
+	TemplateID      string            `json:"template_id" schema:"minLength=1"`
+	TemplateName    string            `json:"template_name"`
+	TemplateConfigs []TemplateConfigs `json:"template_configs"`
+}
+
+type TemplateConfigs struct {
+	TemplateConfigName string `json:"template_config_name"`
+	TemplateType       string `json:"template_type" schema:"enum=TAB_GRID_CHART|TAB_CHART"`
+	BoardType          string `json:"board_type" schema:"enum=DASHBOARD|REPORT"`
+	TemplateConfigID   string `json:"template_config_id" schema:"minLength=1"`
+	Tabs               []Tab  `json:"tabs"`
+}
+
+type Tab struct {
+	Title         string `json:"title"`
+	SubTitle      string `json:"sub_title"`
+	TemplateTabID string `json:"template_tab_id" schema:"minLength=1"`
+	Grids         []Grid `json:"grids"`
+}
+
+type Grid struct {
+	Title          string      `json:"title"`
+	Position       int         `json:"position" schema:"minimum=1"`
+	SubTitle       string      `json:"sub_title"`
+	TemplateGridID string      `json:"template_grid_id" schema:"minLength=1"`
+	Styling        GridStyling `json:"styling"`
+	Charts         []Chart     `json:"charts"`
+}
+
+type GridStyling struct {
+	TitleStyle    GridFontStyle `json:"title_style"`
+	SubTitleStyle GridFontStyle `json:"sub_title_style"`
+}
+
+type GridFontStyle struct {
+	Font       string   `json:"font"`
+	Color      string   `json:"color"`
+	FontSize   int      `json:"font_size" schema:"minimum=0"`
+	FontFormat []string `json:"font_format"`
+}
+
+type Chart struct {
+	ChartType       string       `json:"chart_type" schema:"minLength=1"`
+	Source          string       `json:"source"`
+	Title           string       `json:"title"`
+	TemplateChartID string       `json:"template_chart_id" schema:"minLength=1"`
+	LeftMetrics     []Metric     `json:"left_metrics"`
+	RightMetrics    []Metric     `json:"right_metrics,omitempty"`
+	Dimensions      []Metric     `json:"dimensions,omitempty"`
+	GridPosition    GridPos      `json:"grid_position"`
+	Styling         ChartStyling `json:"styling"`
+}
+
+type ChartStyling struct {
+	Palette        int              `json:"palette" schema:"minimum=0"`
+	TitleStyle     ChartFontStyle   `json:"title_style"`
+	TableStyle     TableTypeStyle   `json:"table_style"`
+	LegendStyle    InsideTableStyle `json:"legend_style"`
+	LegendPosition string           `json:"legend_position"`
+}
+
+type ChartFontStyle struct {
+	Font       string   `json:"font"`
+	Color      string   `json:"color"`
+	FontSize   int      `json:"font_size" schema:"minimum=0"`
+	FontFormat []string `json:"font_format"`
+	Alignment  string   `json:"alignment"`
+}
+
+type TableTypeStyle struct {
+	TableHeader  InsideTableStyle `json:"table_header"`
+	TableContent InsideTableStyle `json:"table_content"`
+}
+
+type InsideTableStyle struct {
+	Font     string `json:"font"`
+	FontSize int    `json:"font_size" schema:"minimum=0"`
+}
+
+type Metric struct {
+	ID                string `json:"id" schema:"minLength=1"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	Type              string `json:"type"`
+	Group             string `json:"group"`
+	Category          string `json:"category"`
+	DataType          string `json:"data_type"`
+	MetricType        string `json:"metric_type"`
+	Description       string `json:"description"`
+	DivideByMillion   bool   `json:"divide_by_million"`
+	AggregationMethod string `json:"aggregation_method"`
+}
+
+type GridPos struct {
+	H    int `json:"h" schema:"minimum=0"`
+	W    int `json:"w" schema:"minimum=0"`
+	X    int `json:"x" schema:"minimum=0"`
+	Y    int `json:"y" schema:"minimum=0"`
+	MaxH int `json:"max_h" schema:"minimum=0"`
+	MinH int `json:"min_h" schema:"minimum=0"`
+	MinW int `json:"min_w" schema:"minimum=0"`
+}
@@ -0,0 +1,128 @@
+package templategen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks an Issue.
+type Severity int
+
+const (
+	// SeverityWarning marks a template that imports but likely not as meant.
+	SeverityWarning Severity = iota
+	// SeverityError marks a template the dashboard app cannot show as is.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a rule broken by a generated template.
+type Issue struct {
+	Severity Severity
+	// Location is the cell that started the object at fault, when known.
+	Location Location
+	// Path is the title path of the object, e.g. "Overview / KPIs / Spend".
+	Path string
+	Msg  string
+}
+
+func (i Issue) String() string {
+	prefix := i.Severity.String() + ": "
+	if cell := i.Location.Cell(); cell != "" {
+		prefix += cell + ": "
+	}
+	return prefix + i.Path + ": " + i.Msg
+}
+
+// Issues are the problems found by Validate, in sheet order.
+type Issues []Issue
+
+// Errors returns the issues of SeverityError.
+func (l Issues) Errors() Issues { return l.filter(SeverityError) }
+
+// Warnings returns the issues of SeverityWarning.
+func (l Issues) Warnings() Issues { return l.filter(SeverityWarning) }
+
+func (l Issues) filter(s Severity) Issues {
+	var out Issues
+	for _, i := range l {
+		if i.Severity == s {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Validate checks the rules a template must follow beyond its schema:
+//
+//   - every chart has a metric (error)
+//   - a Line chart with right metrics has left metrics too (error)
+//   - a TAB_CHART config has at most one chart per grid (error)
+//   - a Pie chart has a single metric (warning)
+//   - the charts of a grid have distinct titles (warning)
+//   - every tab holds a chart (warning)
+//
+// locations, which may be nil, give the sheet cell of each issue.
+func Validate(cfg GlobalTemplateConfig, locations Locations) Issues {
+	var issues Issues
+	report := func(severity Severity, id string, path []string, format string, args ...interface{}) {
+		issues = append(issues, Issue{
+			Severity: severity,
+			Location: locations[id],
+			Path:     strings.Join(path, " / "),
+			Msg:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, config := range cfg.Global.TemplateConfigs {
+		for _, tab := range config.Tabs {
+			charts := 0
+			for _, grid := range tab.Grids {
+				gridPath := []string{tab.Title, grid.Title}
+				charts += len(grid.Charts)
+				if config.TemplateType == TemplateTypeTabChart && len(grid.Charts) > 1 {
+					report(SeverityError, grid.TemplateGridID, gridPath,
+						"%s grid holds %d charts, a %s board allows one", config.BoardType, len(grid.Charts), config.TemplateType)
+				}
+
+				titles := make(map[string]bool)
+				for _, chart := range grid.Charts {
+					chartPath := append(gridPath[:2:2], chart.Title)
+					metrics := len(chart.LeftMetrics) + len(chart.RightMetrics)
+					switch {
+					case metrics == 0:
+						report(SeverityError, chart.TemplateChartID, chartPath, "chart has no metrics")
+					case chart.ChartType == "Line" && len(chart.LeftMetrics) == 0:
+						report(SeverityError, chart.TemplateChartID, chartPath, "Line chart has right metrics but no left metrics")
+					case chart.ChartType == "Pie" && metrics > 1:
+						report(SeverityWarning, chart.TemplateChartID, chartPath, "Pie chart has %d metrics, only the first is shown", metrics)
+					}
+
+					if titles[chart.Title] {
+						report(SeverityWarning, chart.TemplateChartID, chartPath, "another chart of the grid has the same title")
+					}
+					titles[chart.Title] = true
+				}
+			}
+			if charts == 0 {
+				report(SeverityWarning, tab.TemplateTabID, []string{tab.Title}, "tab has no charts")
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i].Location, issues[j].Location
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		return a.Col < b.Col
+	})
+	return issues
+}
@@ -0,0 +1,56 @@
+package templategen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	rows := Rows{
+		Sheet:    "Sheet1",
+		StartRow: 4,
+		StartCol: 1,
+		Values: [][]interface{}{
+			row("Overview", "KPIs", "KPI", "Spend", "", "", "Spend", "spend"),
+			row("", "", "KPI", "Spend", "", "", "Spend", "spend"),
+			row("", "", "Pie", "Split", "Channel", "channel", "Spend", "spend"),
+			row("", "", "", "", "", "", "Clicks", "clicks"),
+			row("", "", "Table", "Empty"),
+			row("Empty"),
+			row("Weekly Report", "Summary", "KPI", "Spend", "", "", "Spend", "spend", "Report"),
+			row("", "", "KPI", "Clicks", "", "", "Clicks", "clicks"),
+		},
+	}
+	cfg, locations, err := (&Parser{}).ParseRowsWithLocations(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a Line chart can only lose its left metrics outside the parser
+	cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0].ChartType = "Line"
+	cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0].RightMetrics = []Metric{{ID: "cpc", Name: "CPC"}}
+	cfg.Global.TemplateConfigs[0].Tabs[0].Grids[0].Charts[0].LeftMetrics = nil
+
+	var got []string
+	for _, issue := range Validate(cfg, locations) {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"error: Sheet1!C4: Overview / KPIs / Spend: Line chart has right metrics but no left metrics",
+		"warning: Sheet1!C5: Overview / KPIs / Spend: another chart of the grid has the same title",
+		"warning: Sheet1!C6: Overview / KPIs / Split: Pie chart has 2 metrics, only the first is shown",
+		"error: Sheet1!C8: Overview / KPIs / Empty: chart has no metrics",
+		"warning: Sheet1!A9: Empty: tab has no charts",
+		"error: Sheet1!B10: Weekly Report / Summary: REPORT grid holds 2 charts, a TAB_CHART board allows one",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues:\n got %q\nwant %q", got, want)
+	}
+
+	issues := Validate(cfg, nil)
+	if len(issues.Errors()) != 3 || len(issues.Warnings()) != 3 {
+		t.Errorf("got %d errors and %d warnings, want 3 and 3", len(issues.Errors()), len(issues.Warnings()))
+	}
+	if s := issues.Errors()[0].String(); s != "error: Overview / KPIs / Spend: Line chart has right metrics but no left metrics" {
+		t.Errorf("issue without location = %q", s)
+	}
+}
@@ -0,0 +1,108 @@
+package templategen
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// SheetSource is a RowSource holding several worksheets, each of which can
+// hold a template.
+type SheetSource interface {
+	RowSource
+	// SheetNames returns the titles of the worksheets, in workbook order.
+	SheetNames(ctx context.Context) ([]string, error)
+	// Sheet returns a source reading the same range of the named worksheet.
+	Sheet(name string) RowSource
+}
+
+// SheetNames returns the worksheet titles of the spreadsheet, as listed by
+// Spreadsheets.Get.
+func (s *SheetsSource) SheetNames(ctx context.Context) ([]string, error) {
+	spreadsheet, err := s.Service.Spreadsheets.Get(s.SpreadsheetID).Fields("sheets.properties.title").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the worksheets of the Google Sheet: %w", err)
+	}
+	var names []string
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil {
+			names = append(names, sheet.Properties.Title)
+		}
+	}
+	return names, nil
+}
+
+func (s *SheetsSource) Sheet(name string) RowSource {
+	sheet := *s
+	sheet.Range = rangeInSheet(s.Range, name)
+	return &sheet
+}
+
+// SheetNames returns the worksheet titles of the workbook.
+func (s *XLSXSource) SheetNames(context.Context) ([]string, error) {
+	f, err := excelize.OpenFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading XLSX: %w", err)
+	}
+	defer f.Close()
+	return f.GetSheetList(), nil
+}
+
+func (s *XLSXSource) Sheet(name string) RowSource {
+	sheet := *s
+	sheet.Range = rangeInSheet(s.Range, name)
+	return &sheet
+}
+
+// rangeInSheet returns cellRange, DefaultRange when empty, in the named
+// worksheet.
+func rangeInSheet(cellRange, sheet string) string {
+	if cellRange == "" {
+		cellRange = DefaultRange
+	}
+	rng, err := ParseRange(cellRange)
+	if err != nil {
+		// left for ReadRows to report
+		return cellRange
+	}
+	rng.Sheet = sheet
+	return rng.String()
+}
+
+// MatchSheets returns the worksheet names matching any of patterns, in the
+// order of names. A pattern is a worksheet title or a glob as for path.Match,
+// so "*" selects every worksheet. A pattern matching no worksheet is an
+// error, so that a misspelt title does not go unnoticed.
+func MatchSheets(names, patterns []string) ([]string, error) {
+	selected := make(map[string]bool)
+	for _, pattern := range patterns {
+		found := false
+		for _, name := range names {
+			// a title such as "Q1 [draft]" is no valid pattern
+			ok := pattern == name
+			if !ok {
+				var err error
+				if ok, err = path.Match(pattern, name); err != nil {
+					return nil, fmt.Errorf("invalid worksheet pattern %q: %w", pattern, err)
+				}
+			}
+			if ok {
+				selected[name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no worksheet matches %q, the worksheets are %s", pattern, strings.Join(names, ", "))
+		}
+	}
+	var out []string
+	for _, name := range names {
+		if selected[name] {
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
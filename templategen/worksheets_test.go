@@ -0,0 +1,89 @@
+package templategen
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMatchSheets(t *testing.T) {
+	names := []string{"Retail", "Retail EU", "Travel", "Q1 [draft]"}
+	tests := []struct {
+		patterns []string
+		want     []string
+	}{
+		{[]string{"*"}, names},
+		{[]string{"Travel", "Retail*"}, []string{"Retail", "Retail EU", "Travel"}},
+		{[]string{"Q1 [draft]"}, []string{"Q1 [draft]"}},
+	}
+	for _, tt := range tests {
+		got, err := MatchSheets(names, tt.patterns)
+		if err != nil {
+			t.Errorf("MatchSheets(%q): %v", tt.patterns, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MatchSheets(%q) = %q, want %q", tt.patterns, got, tt.want)
+		}
+	}
+	if _, err := MatchSheets(names, []string{"Retial"}); err == nil {
+		t.Error("pattern matching no worksheet: no error")
+	}
+}
+
+func TestXLSXSheets(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetSheetName("Sheet1", "Retail")
+	f.NewSheet("Travel")
+	f.SetSheetRow("Retail", "A4", &[]interface{}{"Overview", "KPIs", "KPI", "Spend"})
+	f.SetSheetRow("Travel", "A4", &[]interface{}{"Bookings", "KPIs", "KPI", "Trips"})
+	path := filepath.Join(t.TempDir(), "verticals.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var source SheetSource = &XLSXSource{Path: path}
+	names, err := source.SheetNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Retail", "Travel"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("SheetNames = %q, want %q", names, want)
+	}
+	rows, err := source.Sheet("Travel").ReadRows(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Sheet != "Travel" || rows.StartRow != 4 || len(rows.Values) != 1 || rows.Values[0][0] != "Bookings" {
+		t.Errorf("rows of Travel = %+v", rows)
+	}
+}
+
+func TestRangeInSheet(t *testing.T) {
+	tests := []struct {
+		sheet, want string
+	}{
+		{"Retail", "Retail!A4:J"},
+		{"Sheet_2", "Sheet_2!A4:J"},
+		{"2024", "'2024'!A4:J"},
+		{"Retail-EU", "'Retail-EU'!A4:J"},
+		{"Q1 Report", "'Q1 Report'!A4:J"},
+		{"A1", "'A1'!A4:J"},
+		{"FY2024", "'FY2024'!A4:J"},
+		{"R1C1", "'R1C1'!A4:J"},
+		{"Bob's", "'Bob''s'!A4:J"},
+		{"'quoted'", "'''quoted'''!A4:J"},
+	}
+	for _, tt := range tests {
+		got := rangeInSheet("", tt.sheet)
+		if got != tt.want {
+			t.Errorf("rangeInSheet(%q) = %s, want %s", tt.sheet, got, tt.want)
+		}
+		if rng, err := ParseRange(got); err != nil || rng.Sheet != tt.sheet {
+			t.Errorf("ParseRange(%s) = %+v, %v, want sheet %q", got, rng, err, tt.sheet)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package templategen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ReadXLSX reads template rows from the cells of an .xlsx workbook covered by
+// cellRange, e.g. "Sheet1!A4:J". Without a sheet name in the range the first
+// worksheet is used.
+//
+// As with ReadCSV, the rows have the shape returned by the Sheets API for the
+// same range.
+func ReadXLSX(r io.Reader, cellRange string) ([][]interface{}, error) {
+	rows, err := readXLSX(r, cellRange)
+	return rows.Values, err
+}
+
+// XLSXSource reads the template rows from a worksheet of an .xlsx file.
+type XLSXSource struct {
+	Path string
+	// Range is the A1 range to read, DefaultRange when empty.
+	Range string
+}
+
+func (s *XLSXSource) ReadRows(context.Context) (Rows, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return Rows{}, err
+	}
+	defer f.Close()
+
+	cellRange := s.Range
+	if cellRange == "" {
+		cellRange = DefaultRange
+	}
+	return readXLSX(f, cellRange)
+}
+
+// WriteRows writes rows to the workbook at the file, in the sheet and at the
+// top-left cell of Range, or at their own when Range is empty. As with
+// SheetsSource.WriteRows, the sheet is cleared from that cell on first; the
+// other sheets of an existing workbook are kept. A missing file or sheet is
+// created.
+func (s *XLSXSource) WriteRows(_ context.Context, rows Rows) error {
+	rows, err := rows.at(s.Range)
+	if err != nil {
+		return err
+	}
+
+	var f *excelize.File
+	if _, err := os.Stat(s.Path); errors.Is(err, fs.ErrNotExist) {
+		f, err = newWorkbook(rows.Sheet)
+		if err != nil {
+			return err
+		}
+	} else if f, err = excelize.OpenFile(s.Path); err != nil {
+		return fmt.Errorf("reading XLSX: %w", err)
+	}
+	defer f.Close()
+
+	if err := writeSheet(f, rows); err != nil {
+		return fmt.Errorf("%s: %w", s.Path, err)
+	}
+	return f.SaveAs(s.Path)
+}
+
+// WriteXLSX writes rows as a workbook with one worksheet, placed in the sheet
+// and at the top-left cell of cellRange, or at their own when it is empty.
+func WriteXLSX(w io.Writer, rows Rows, cellRange string) error {
+	rows, err := rows.at(cellRange)
+	if err != nil {
+		return err
+	}
+	f, err := newWorkbook(rows.Sheet)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := writeSheet(f, rows); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// newWorkbook returns an empty workbook whose one worksheet is called sheet,
+// Sheet1 when empty.
+func newWorkbook(sheet string) (*excelize.File, error) {
+	f := excelize.NewFile()
+	if sheet == "" {
+		return f, nil
+	}
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// writeSheet clears the sheet of rows, the first worksheet when unnamed, from
+// the top-left cell of rows on and writes rows there.
+func writeSheet(f *excelize.File, rows Rows) error {
+	sheet := rows.Sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	if index, err := f.GetSheetIndex(sheet); err != nil {
+		return err
+	} else if index < 0 {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+	}
+
+	old, err := f.GetRows(sheet)
+	if err != nil {
+		return err
+	}
+	for r := rows.StartRow - 1; r < len(old); r++ {
+		for c := rows.StartCol - 1; c < min(len(old[r]), clearColumns); c++ {
+			if old[r][c] == "" {
+				continue
+			}
+			cell, err := excelize.CoordinatesToCellName(c+1, r+1)
+			if err != nil {
+				return err
+			}
+			if err := f.SetCellValue(sheet, cell, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, row := range rows.Values {
+		cell, err := excelize.CoordinatesToCellName(rows.StartCol, rows.StartRow+i)
+		if err != nil {
+			return err
+		}
+		values := row
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readXLSX(r io.Reader, cellRange string) (Rows, error) {
+	rng, err := ParseRange(cellRange)
+	if err != nil {
+		return Rows{}, err
+	}
+
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return Rows{}, fmt.Errorf("reading XLSX: %w", err)
+	}
+	defer f.Close()
+
+	sheet := rng.Sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	records, err := f.GetRows(sheet)
+	if err != nil {
+		return Rows{}, fmt.Errorf("reading worksheet %q: %w", sheet, err)
+	}
+
+	var rows [][]interface{}
+	for i := rng.StartRow - 1; i < len(records); i++ {
+		if rng.EndRow > 0 && i >= rng.EndRow {
+			break
+		}
+		record := records[i]
+		if len(record) < rng.StartCol {
+			rows = append(rows, []interface{}{})
+			continue
+		}
+		record = record[rng.StartCol-1:]
+		if rng.EndCol > 0 && len(record) > rng.EndCol-rng.StartCol+1 {
+			record = record[:rng.EndCol-rng.StartCol+1]
+		}
+		rows = append(rows, trimRow(record))
+	}
+	return Rows{
+		Sheet:    sheet,
+		StartRow: rng.StartRow,
+		StartCol: rng.StartCol,
+		Values:   trimRows(rows),
+	}, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/surya-pixis/template-generator/templategen"
+)
+
+// runSheets generates a template from each worksheet selected by -sheets,
+// skipping empty ones. A failing worksheet does not stop the others.
+func (g *generator) runSheets(ctx context.Context, source templategen.RowSource, logger *log.Logger) error {
+	sheets, ok := source.(templategen.SheetSource)
+	if !ok {
+		return errors.New("-sheets needs a Google Sheet or an XLSX workbook")
+	}
+	names, err := sheets.SheetNames(ctx)
+	if err != nil {
+		return err
+	}
+	selected, err := templategen.MatchSheets(names, strings.Split(g.cfg.Sheets, ","))
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]job, len(selected))
+	written := make(map[string]string) // output path -> worksheet
+	for i, name := range selected {
+		output := sheetPath(g.cfg.OutputPath, name)
+		if other, ok := written[output]; ok {
+			return fmt.Errorf("worksheets %q and %q would both be written to %s", other, name, output)
+		}
+		written[output] = name
+		jobs[i] = job{
+			name:     name,
+			output:   output,
+			previous: sheetPath(g.cfg.PreviousPath, name),
+			log:      log.New(logger.Writer(), logger.Prefix()+name+": ", logger.Flags()),
+		}
+	}
+
+	failed := 0
+	for i, name := range selected {
+		rows, err := sheets.Sheet(name).ReadRows(ctx)
+		if err == nil && len(rows.Values) == 0 {
+			// such as a notes worksheet selected by *
+			jobs[i].log.Print("no template rows, skipped")
+			continue
+		}
+		if err == nil {
+			err = g.generate(rows, jobs[i])
+		}
+		if err != nil {
+			jobs[i].log.Print(err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d worksheets failed", failed, len(selected))
+	}
+	return nil
+}
+
+// sheetPath returns the path of the file for the named worksheet: path with
+// "{sheet}" replaced by the worksheet title in file name form, or with that
+// added before the extension. An empty path stays empty.
+func sheetPath(path, sheet string) string {
+	if path == "" {
+		return ""
+	}
+	name := fileName(sheet)
+	if strings.Contains(path, "{sheet}") {
+		return strings.ReplaceAll(path, "{sheet}", name)
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + name + ext
+}
+
+// sheetPathPattern returns a regexp matching every path sheetPath gives for
+// path, whatever the worksheet.
+func sheetPathPattern(path string) *regexp.Regexp {
+	// a file name as fileName makes it, or one with other letters
+	const name = `[\pL\pN]+(?:_[\pL\pN]+)*`
+	if strings.Contains(path, "{sheet}") {
+		parts := strings.Split(path, "{sheet}")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		return regexp.MustCompile("^" + strings.Join(parts, name) + "$")
+	}
+	ext := filepath.Ext(path)
+	return regexp.MustCompile("^" + regexp.QuoteMeta(strings.TrimSuffix(path, ext)) + "_" + name + regexp.QuoteMeta(ext) + "$")
+}
+
+// fileName turns a worksheet title such as "Retail (EU)" into a file name
+// such as "retail_eu".
+func fileName(title string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	if b.Len() == 0 {
+		return "sheet"
+	}
+	return b.String()
+}